@@ -0,0 +1,55 @@
+package authbackend
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrInvalidCredentials is returned when a backend does not accept the email and password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrPasswordResetRequired is returned when an administrator requires a new password first
+	ErrPasswordResetRequired = errors.New("password reset required")
+	// ErrAccountConflict is returned when the credentials belong to an external account whose
+	// email address is taken by a local account that cannot be linked automatically
+	ErrAccountConflict = errors.New("account conflict")
+	// ErrAccountDeleted is returned when the credentials belong to an external account whose
+	// local account has been deleted
+	ErrAccountDeleted = errors.New("account deleted")
+)
+
+// Backend verifies a password login and returns the local user it belongs to
+type Backend interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Authenticate returns ErrInvalidCredentials when the backend does not accept the credentials
+	// and any other error when it could not check them
+	Authenticate(email, password string) (*models.User, error)
+}
+
+// NewFromEnv creates the backends listed in AUTH_BACKENDS (local, ldap; default: local)
+// in the order logins try them
+func NewFromEnv() []Backend {
+	var backends []Backend
+	for _, name := range strings.Split(utils.GetEnv("AUTH_BACKENDS", "local"), ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "local":
+			backends = append(backends, &LocalBackend{})
+		case "ldap":
+			backends = append(backends, NewLDAPBackendFromEnv())
+		default:
+			log.Printf("Unknown auth backend %q, ignoring it", name)
+		}
+	}
+
+	if len(backends) == 0 {
+		log.Println("No auth backends configured, falling back to local passwords")
+		backends = append(backends, &LocalBackend{})
+	}
+	return backends
+}
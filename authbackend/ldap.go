@@ -0,0 +1,290 @@
+package authbackend
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// ldapProvider names LDAP identities linked to local users
+const ldapProvider = "ldap"
+
+// LDAPConn is the part of an LDAP connection used by the backend. *ldap.Conn implements it;
+// an in-process fake can be plugged in through LDAPBackend.Dial.
+type LDAPConn interface {
+	Bind(username, password string) error
+	Search(request *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAPGroupRole maps members of a directory group to a local role
+type LDAPGroupRole struct {
+	GroupDN *ldap.DN
+	Role    string
+}
+
+// LDAPBackend authenticates against a directory: it looks up the user's entry (with the
+// service account when BindDN is set) and binds as that entry with the supplied password.
+// Users are created just in time, and on every login the roles in GroupRoles are granted
+// or revoked to match the user's directory groups.
+type LDAPBackend struct {
+	// Dial opens a connection to the directory
+	Dial func() (LDAPConn, error)
+
+	BindDN       string
+	BindPassword string
+
+	BaseDN         string
+	UserFilter     string // %s is replaced with the escaped login email
+	EmailAttribute string
+	NameAttribute  string
+
+	// Groups are read from GroupAttribute of the user entry and, when GroupFilter is set,
+	// searched below GroupBaseDN (%s is replaced with the escaped user DN)
+	GroupAttribute string
+	GroupBaseDN    string
+	GroupFilter    string
+	GroupRoles     []LDAPGroupRole
+}
+
+// NewLDAPBackendFromEnv creates an LDAP backend configured by the LDAP_* environment variables
+func NewLDAPBackendFromEnv() *LDAPBackend {
+	baseDN := utils.GetEnv("LDAP_BASE_DN", "")
+
+	return &LDAPBackend{
+		Dial: DialLDAP(
+			utils.GetEnv("LDAP_URL", "ldap://localhost:389"),
+			utils.GetEnvBool("LDAP_START_TLS", false),
+			utils.GetEnvBool("LDAP_TLS_INSECURE_SKIP_VERIFY", false),
+			utils.GetEnvDuration("LDAP_TIMEOUT_SECONDS", time.Second, 5*time.Second),
+		),
+		BindDN:         utils.GetEnv("LDAP_BIND_DN", ""),
+		BindPassword:   utils.GetEnv("LDAP_BIND_PASSWORD", ""),
+		BaseDN:         baseDN,
+		UserFilter:     utils.GetEnv("LDAP_USER_FILTER", "(&(objectClass=inetOrgPerson)(mail=%s))"),
+		EmailAttribute: utils.GetEnv("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		NameAttribute:  utils.GetEnv("LDAP_NAME_ATTRIBUTE", "cn"),
+		GroupAttribute: utils.GetEnv("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+		GroupBaseDN:    utils.GetEnv("LDAP_GROUP_BASE_DN", baseDN),
+		GroupFilter:    utils.GetEnv("LDAP_GROUP_FILTER", "(&(objectClass=groupOfNames)(member=%s))"),
+		GroupRoles:     ParseLDAPGroupRoles(utils.GetEnv("LDAP_GROUP_ROLES", "")),
+	}
+}
+
+// DialLDAP returns a dialer for ldap:// or ldaps:// URLs, optionally upgrading plain connections with StartTLS
+func DialLDAP(rawURL string, startTLS, insecureSkipVerify bool, timeout time.Duration) func() (LDAPConn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if parsed, err := url.Parse(rawURL); err == nil {
+		tlsConfig.ServerName = parsed.Hostname()
+	}
+
+	return func() (LDAPConn, error) {
+		conn, err := ldap.DialURL(rawURL,
+			ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+			ldap.DialWithTLSConfig(tlsConfig))
+		if err != nil {
+			return nil, err
+		}
+		conn.SetTimeout(timeout)
+
+		if startTLS {
+			if err := conn.StartTLS(tlsConfig); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+}
+
+// ParseLDAPGroupRoles parses "groupDN:role" pairs separated by semicolons
+func ParseLDAPGroupRoles(value string) []LDAPGroupRole {
+	var mappings []LDAPGroupRole
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(pair, ":")
+		if separator <= 0 {
+			log.Printf("LDAP_GROUP_ROLES: ignoring %q, expected groupDN:role", pair)
+			continue
+		}
+
+		groupDN, err := ldap.ParseDN(strings.TrimSpace(pair[:separator]))
+		if err != nil {
+			log.Printf("LDAP_GROUP_ROLES: ignoring invalid group DN %q: %v", pair[:separator], err)
+			continue
+		}
+		mappings = append(mappings, LDAPGroupRole{GroupDN: groupDN, Role: strings.TrimSpace(pair[separator+1:])})
+	}
+	return mappings
+}
+
+// Name identifies the backend in logs
+func (b *LDAPBackend) Name() string {
+	return ldapProvider
+}
+
+// Authenticate binds as the directory entry of the email address and provisions the local user
+func (b *LDAPBackend) Authenticate(email, password string) (*models.User, error) {
+	// An empty password would be an unauthenticated bind, which many servers accept
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := b.Dial()
+	if err != nil {
+		return nil, fmt.Errorf("ldap: connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Find the user's entry
+	if err := b.bindServiceAccount(conn); err != nil {
+		return nil, err
+	}
+	entry, err := b.findUser(conn, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the password by binding as the user
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: bind as user: %w", err)
+	}
+
+	// Map group memberships to roles
+	groups, err := b.userGroups(conn, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	entryEmail := entry.GetAttributeValue(b.EmailAttribute)
+	if entryEmail == "" {
+		entryEmail = email
+	}
+
+	user, err := services.ProvisionDirectoryUser(services.DirectoryIdentity{
+		Provider: ldapProvider,
+		Subject:  entry.DN,
+		Email:    entryEmail,
+		Name:     entry.GetAttributeValue(b.NameAttribute),
+		Roles:    b.rolesForGroups(groups),
+	}, b.managedRoles())
+	switch err {
+	case services.ErrDirectoryAccountConflict:
+		return nil, ErrAccountConflict
+	case services.ErrDirectoryAccountDeleted:
+		return nil, ErrAccountDeleted
+	}
+	return user, err
+}
+
+// bindServiceAccount authenticates the connection for searches; without a bind DN searches are anonymous
+func (b *LDAPBackend) bindServiceAccount(conn LDAPConn) error {
+	if b.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(b.BindDN, b.BindPassword); err != nil {
+		return fmt.Errorf("ldap: bind as service account: %w", err)
+	}
+	return nil
+}
+
+// findUser returns the single entry matching the login email
+func (b *LDAPBackend) findUser(conn LDAPConn, email string) (*ldap.Entry, error) {
+	attributes := []string{b.EmailAttribute, b.NameAttribute}
+	if b.GroupAttribute != "" {
+		attributes = append(attributes, b.GroupAttribute)
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		b.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(b.UserFilter, ldap.EscapeFilter(email)),
+		attributes, nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) || ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: search user: %w", err)
+	}
+
+	// Unknown or ambiguous addresses cannot sign in
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return result.Entries[0], nil
+}
+
+// userGroups collects the DNs of the groups the user belongs to
+func (b *LDAPBackend) userGroups(conn LDAPConn, entry *ldap.Entry) ([]string, error) {
+	var groups []string
+	if b.GroupAttribute != "" {
+		groups = append(groups, entry.GetAttributeValues(b.GroupAttribute)...)
+	}
+	if b.GroupFilter == "" || len(b.GroupRoles) == 0 {
+		return groups, nil
+	}
+
+	// The user may not be allowed to search groups, so switch back to the service account
+	if err := b.bindServiceAccount(conn); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		b.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(b.GroupFilter, ldap.EscapeFilter(entry.DN)),
+		[]string{"1.1"}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: search groups: %w", err)
+	}
+	for _, group := range result.Entries {
+		groups = append(groups, group.DN)
+	}
+	return groups, nil
+}
+
+// rolesForGroups returns the roles mapped from the given group DNs
+func (b *LDAPBackend) rolesForGroups(groups []string) []string {
+	var roles []string
+	for _, group := range groups {
+		groupDN, err := ldap.ParseDN(group)
+		if err != nil {
+			continue
+		}
+		for _, mapping := range b.GroupRoles {
+			if mapping.GroupDN.EqualFold(groupDN) {
+				roles = append(roles, mapping.Role)
+			}
+		}
+	}
+	return roles
+}
+
+// managedRoles returns every role the group mapping controls
+func (b *LDAPBackend) managedRoles() []string {
+	seen := make(map[string]bool, len(b.GroupRoles))
+	var roles []string
+	for _, mapping := range b.GroupRoles {
+		if !seen[mapping.Role] {
+			seen[mapping.Role] = true
+			roles = append(roles, mapping.Role)
+		}
+	}
+	return roles
+}
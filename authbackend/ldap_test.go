@@ -0,0 +1,341 @@
+package authbackend
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/services"
+)
+
+const (
+	testBaseDN      = "ou=users,dc=notes,dc=test"
+	testGroupBaseDN = "ou=groups,dc=notes,dc=test"
+	testBindDN      = "cn=admin,dc=notes,dc=test"
+	testAdminsDN    = "cn=admins,ou=groups,dc=notes,dc=test"
+	testAuditorsDN  = "cn=auditors,ou=groups,dc=notes,dc=test"
+	testAliceDN     = "uid=alice,ou=users,dc=notes,dc=test"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+// fakeDirectory is an in-memory directory answering the binds and searches the backend makes
+type fakeDirectory struct {
+	passwords map[string]string
+	users     []*ldap.Entry
+	// groups maps group DNs to the DNs of their members
+	groups map[string][]string
+}
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		passwords: map[string]string{
+			testBindDN:  "service-password",
+			testAliceDN: "alice-password",
+		},
+		users: []*ldap.Entry{
+			ldap.NewEntry(testAliceDN, map[string][]string{
+				"mail": {"alice@example.com"},
+				"cn":   {"Alice Directory"},
+			}),
+		},
+		groups: map[string][]string{
+			testAdminsDN: {testAliceDN},
+		},
+	}
+}
+
+func (d *fakeDirectory) dial() (LDAPConn, error) {
+	return &fakeLDAPConn{directory: d}, nil
+}
+
+// fakeLDAPConn is a connection to a fakeDirectory
+type fakeLDAPConn struct {
+	directory *fakeDirectory
+	boundDN   string
+}
+
+func (c *fakeLDAPConn) Bind(username, password string) error {
+	if expected, ok := c.directory.passwords[username]; !ok || password == "" || password != expected {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	c.boundDN = username
+	return nil
+}
+
+func (c *fakeLDAPConn) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if c.boundDN != testBindDN {
+		return nil, ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("searches require the service account"))
+	}
+
+	result := &ldap.SearchResult{}
+	switch request.BaseDN {
+	case testBaseDN:
+		for _, entry := range c.directory.users {
+			if strings.Contains(request.Filter, "(mail="+ldap.EscapeFilter(entry.GetAttributeValue("mail"))+")") {
+				result.Entries = append(result.Entries, entry)
+			}
+		}
+	case testGroupBaseDN:
+		for groupDN, members := range c.directory.groups {
+			for _, member := range members {
+				if strings.Contains(request.Filter, "(member="+ldap.EscapeFilter(member)+")") {
+					result.Entries = append(result.Entries, ldap.NewEntry(groupDN, nil))
+				}
+			}
+		}
+	default:
+		return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("no such object"))
+	}
+	return result, nil
+}
+
+func (c *fakeLDAPConn) Close() error {
+	return nil
+}
+
+// newTestBackend returns an LDAP backend connected to the directory that maps admins and auditors to roles
+func newTestBackend(directory *fakeDirectory) *LDAPBackend {
+	return &LDAPBackend{
+		Dial:           directory.dial,
+		BindDN:         testBindDN,
+		BindPassword:   "service-password",
+		BaseDN:         testBaseDN,
+		UserFilter:     "(&(objectClass=inetOrgPerson)(mail=%s))",
+		EmailAttribute: "mail",
+		NameAttribute:  "cn",
+		GroupBaseDN:    testGroupBaseDN,
+		GroupFilter:    "(&(objectClass=groupOfNames)(member=%s))",
+		GroupRoles:     ParseLDAPGroupRoles(testAdminsDN + ":admin;" + testAuditorsDN + ":auditor"),
+	}
+}
+
+// setupTestDB points config.DB at a fresh in-memory database holding the mapped roles
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared&_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	config.DB = db
+	if err := config.Migrate(); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	services.Users = services.NewUserCache()
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	for _, name := range []string{"admin", "auditor"} {
+		if err := db.Create(&models.Role{Name: name}).Error; err != nil {
+			t.Fatalf("create role: %v", err)
+		}
+	}
+}
+
+// userRoles returns the names of the roles the user holds
+func userRoles(t *testing.T, userID uint) []string {
+	t.Helper()
+
+	var user models.User
+	if err := config.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	return user.RoleNames()
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{name: "valid credentials", email: "alice@example.com", password: "alice-password"},
+		{name: "wrong password", email: "alice@example.com", password: "wrong", wantErr: ErrInvalidCredentials},
+		{name: "empty password", email: "alice@example.com", password: "", wantErr: ErrInvalidCredentials},
+		{name: "unknown email", email: "bob@example.com", password: "alice-password", wantErr: ErrInvalidCredentials},
+		{name: "filter injection", email: "*)(mail=*", password: "alice-password", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+
+			user, err := newTestBackend(newFakeDirectory()).Authenticate(tt.email, tt.password)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if user.Email != "alice@example.com" || user.Name != "Alice Directory" || !user.IsEmailVerified() || user.HasLocalPassword() {
+				t.Errorf("Authenticate() user = %+v", user)
+			}
+		})
+	}
+}
+
+func TestLDAPAuthenticateServiceAccountFailure(t *testing.T) {
+	setupTestDB(t)
+
+	backend := newTestBackend(newFakeDirectory())
+	backend.BindPassword = "wrong"
+
+	if _, err := backend.Authenticate("alice@example.com", "alice-password"); err == nil || err == ErrInvalidCredentials {
+		t.Fatalf("Authenticate() error = %v, want a connection error", err)
+	}
+}
+
+func TestLDAPAuthenticateExistingAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		passwordUnset bool
+		role          string
+		deleted       bool
+		wantErr       error
+	}{
+		{name: "account with its own password", wantErr: ErrAccountConflict},
+		{name: "account with a managed role", passwordUnset: true, role: "admin", wantErr: ErrAccountConflict},
+		{name: "deleted account", passwordUnset: true, deleted: true, wantErr: ErrAccountDeleted},
+		{name: "account from another identity provider", passwordUnset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			db := config.GetDB()
+
+			existing := models.User{Name: "Alice", Email: "alice@example.com", Password: "unused", PasswordUnset: tt.passwordUnset}
+			if err := db.Create(&existing).Error; err != nil {
+				t.Fatalf("create user: %v", err)
+			}
+			if tt.role != "" {
+				var role models.Role
+				db.Where("name = ?", tt.role).First(&role)
+				if err := db.Model(&existing).Association("Roles").Append(&role); err != nil {
+					t.Fatalf("assign role: %v", err)
+				}
+			}
+			if tt.deleted {
+				db.Delete(&existing)
+			}
+
+			user, err := newTestBackend(newFakeDirectory()).Authenticate("alice@example.com", "alice-password")
+			if err != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var identities int64
+			db.Model(&models.UserIdentity{}).Where("user_id = ?", existing.ID).Count(&identities)
+			if tt.wantErr != nil {
+				if identities != 0 {
+					t.Error("a refused account was linked to the directory")
+				}
+				return
+			}
+			if user.ID != existing.ID || identities != 1 {
+				t.Errorf("Authenticate() user = %d with %d identities, want the existing user linked once", user.ID, identities)
+			}
+		})
+	}
+}
+
+func TestLDAPAuthenticateDeletedLinkedAccount(t *testing.T) {
+	setupTestDB(t)
+	backend := newTestBackend(newFakeDirectory())
+
+	user, err := backend.Authenticate("alice@example.com", "alice-password")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if err := config.GetDB().Delete(user).Error; err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	if _, err := backend.Authenticate("alice@example.com", "alice-password"); err != ErrAccountDeleted {
+		t.Fatalf("Authenticate() error = %v, want ErrAccountDeleted", err)
+	}
+	var users int64
+	config.GetDB().Unscoped().Model(&models.User{}).Count(&users)
+	if users != 1 {
+		t.Errorf("%d users exist, want the deleted account only", users)
+	}
+}
+
+func TestLDAPGroupRoles(t *testing.T) {
+	setupTestDB(t)
+	directory := newFakeDirectory()
+	backend := newTestBackend(directory)
+
+	login := func() *models.User {
+		t.Helper()
+		user, err := backend.Authenticate("alice@example.com", "alice-password")
+		if err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+		return user
+	}
+
+	// Members of the admins group are granted the admin role
+	user := login()
+	if roles := userRoles(t, user.ID); strings.Join(roles, ",") != "admin" {
+		t.Fatalf("roles after first login = %v, want [admin]", roles)
+	}
+
+	// An administrator assigns the auditor role by hand
+	if _, err := services.AssignRole(user.ID, "auditor"); err != nil {
+		t.Fatalf("AssignRole() error = %v", err)
+	}
+
+	// Leaving the admins group revokes the admin role, but not the role assigned by hand,
+	// and signs out the sessions that held it
+	pair, err := services.IssueTokenPair(user, services.SessionMeta{})
+	if err != nil {
+		t.Fatalf("IssueTokenPair() error = %v", err)
+	}
+	directory.groups[testAdminsDN] = nil
+	login()
+	if roles := userRoles(t, user.ID); strings.Join(roles, ",") != "auditor" {
+		t.Fatalf("roles after leaving the group = %v, want [auditor]", roles)
+	}
+	if _, err := services.GetActiveSession(user.ID, pair.SessionID); err == nil {
+		t.Error("a session survived the revocation of a directory role")
+	}
+
+	// Joining and leaving the auditors group keeps the auditor role assigned by hand
+	directory.groups[testAuditorsDN] = []string{testAliceDN}
+	login()
+	directory.groups[testAuditorsDN] = nil
+	login()
+	if roles := userRoles(t, user.ID); strings.Join(roles, ",") != "auditor" {
+		t.Fatalf("roles after leaving the auditors group = %v, want [auditor]", roles)
+	}
+
+	// Rejoining the admins group grants the admin role again
+	directory.groups[testAdminsDN] = []string{testAliceDN}
+	login()
+	if roles := userRoles(t, user.ID); strings.Join(roles, ",") != "admin,auditor" {
+		t.Fatalf("roles after rejoining = %v, want [admin auditor]", roles)
+	}
+}
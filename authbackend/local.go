@@ -0,0 +1,52 @@
+package authbackend
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/services"
+)
+
+// LocalBackend checks the password hashes stored in the users table
+type LocalBackend struct{}
+
+// Name identifies the backend in logs
+func (b *LocalBackend) Name() string {
+	return "local"
+}
+
+// Authenticate checks the user's stored password
+func (b *LocalBackend) Authenticate(email, password string) (*models.User, error) {
+	// Find user by email
+	var user models.User
+	if err := config.GetDB().Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Check password
+	if !user.CheckPassword(password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Store the upgraded hash when the password used outdated hashing parameters
+	if user.PasswordRehashed() {
+		if err := config.GetDB().Model(&user).Update("password", user.Password).Error; err != nil {
+			log.Println("Failed to store rehashed password:", err)
+		} else {
+			services.Users.Invalidate(user.ID)
+		}
+	}
+
+	// Administrators can require a new password before the old one is accepted again
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	return &user, nil
+}
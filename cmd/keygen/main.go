@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	alg := flag.String("alg", "EdDSA", "signing algorithm: EdDSA or RS256")
+	dir := flag.String("dir", "keys", "directory the key files are written to")
+	kid := flag.String("kid", time.Now().UTC().Format("2006-01-02"), "key ID placed in the kid header")
+	flag.Parse()
+
+	// Generate key pair
+	var private crypto.Signer
+	var err error
+	switch *alg {
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		log.Fatalf("Unsupported algorithm %q", *alg)
+	}
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+
+	// Write private and public key files
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatal("Failed to create key directory:", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		log.Fatal("Failed to encode private key:", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		log.Fatal("Failed to encode public key:", err)
+	}
+
+	privatePath := filepath.Join(*dir, *kid+".pem")
+	publicPath := filepath.Join(*dir, *kid+".pub.pem")
+	if err := writePEM(privatePath, "PRIVATE KEY", privateDER, 0o600); err != nil {
+		log.Fatal("Failed to write private key:", err)
+	}
+	if err := writePEM(publicPath, "PUBLIC KEY", publicDER, 0o644); err != nil {
+		log.Fatal("Failed to write public key:", err)
+	}
+
+	fmt.Printf("Wrote %s and %s\n", privatePath, publicPath)
+	fmt.Printf("Prepend \"%s=%s\" to JWT_KEYS to start signing with it\n", *kid, privatePath)
+}
+
+// writePEM writes a single PEM block without overwriting existing files
+func writePEM(path, blockType string, der []byte, mode os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return pem.Encode(file, &pem.Block{Type: blockType, Bytes: der})
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the running API")
+	email := flag.String("email", "john@example.com", "email of the user to sign in as")
+	password := flag.String("password", "password123", "password of the user to sign in as")
+	path := flag.String("path", "/api/v1/notes", "protected endpoint requested by every worker")
+	concurrency := flag.Int("concurrency", 50, "number of concurrent workers")
+	duration := flag.Duration("duration", 30*time.Second, "how long to generate load")
+	flag.Parse()
+
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+
+	// Sign in once; every worker reuses the access token
+	token, err := login(client, *baseURL, *email, *password)
+	if err != nil {
+		log.Fatal("Failed to sign in:", err)
+	}
+
+	// Generate load
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  atomic.Int64
+		wg        sync.WaitGroup
+	)
+	deadline := time.Now().Add(*duration)
+	started := time.Now()
+
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var local []time.Duration
+			for time.Now().Before(deadline) {
+				begin := time.Now()
+				if err := get(client, *baseURL+*path, token); err != nil {
+					failures.Add(1)
+					continue
+				}
+				local = append(local, time.Since(begin))
+			}
+
+			mu.Lock()
+			latencies = append(latencies, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	// Report latency percentiles
+	if len(latencies) == 0 {
+		log.Fatalf("All %d requests failed", failures.Load())
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	fmt.Printf("Requests:    %d (%d failed)\n", len(latencies), failures.Load())
+	fmt.Printf("Throughput:  %.1f req/s\n", float64(len(latencies))/elapsed.Seconds())
+	fmt.Printf("Latency p50: %s\n", percentile(latencies, 0.50))
+	fmt.Printf("Latency p90: %s\n", percentile(latencies, 0.90))
+	fmt.Printf("Latency p99: %s\n", percentile(latencies, 0.99))
+	fmt.Printf("Latency max: %s\n", latencies[len(latencies)-1])
+
+	// Report the user cache hit rate when the server exposes metrics
+	if stats, err := userCacheStats(client, *baseURL); err != nil {
+		fmt.Println("User cache:  unavailable (start the API with METRICS_ENABLED=true)")
+	} else {
+		fmt.Printf("User cache:  %s\n", stats)
+	}
+}
+
+// login signs in with a password and returns the access token
+func login(client *http.Client, baseURL, email, password string) (string, error) {
+	body, err := json.Marshal(map[string]string{"email": email, "password": password})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Post(baseURL+"/api/v1/auth/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Message string `json:"message"`
+		Data    struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK || result.Data.Token == "" {
+		return "", fmt.Errorf("login returned %d: %s", resp.StatusCode, result.Message)
+	}
+	return result.Data.Token, nil
+}
+
+// get requests a protected endpoint and drains the response so the connection is reused
+func get(client *http.Client, url, token string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// userCacheStats fetches the user cache counters from /debug/vars
+func userCacheStats(client *http.Client, baseURL string) (string, error) {
+	resp, err := client.Get(baseURL + "/debug/vars?r=user_cache")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var vars struct {
+		UserCache *struct {
+			Hits    uint64  `json:"hits"`
+			Misses  uint64  `json:"misses"`
+			HitRate float64 `json:"hit_rate"`
+		} `json:"user_cache"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&vars); err != nil {
+		return "", err
+	}
+	if vars.UserCache == nil {
+		return "", fmt.Errorf("user_cache metrics not published")
+	}
+	return fmt.Sprintf("%.1f%% hit rate (%d hits, %d misses)", vars.UserCache.HitRate*100, vars.UserCache.Hits, vars.UserCache.Misses), nil
+}
+
+// percentile returns the latency below which the given fraction of sorted samples fall
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	index := int(float64(len(sorted)-1) * p)
+	return sorted[index]
+}
@@ -38,14 +38,39 @@ func ConnectDB() {
 	log.Println("Database connected successfully")
 
 	// Auto migrate the schema
-	err = DB.AutoMigrate(&models.User{}, &models.Note{})
-	if err != nil {
+	if err := Migrate(); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	log.Println("Database migration completed")
 }
 
+// Migrate runs the schema migrations for all models
+func Migrate() error {
+	return DB.AutoMigrate(
+		&models.Role{},
+		&models.User{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
+		&models.WorkspaceInvitation{},
+		&models.Notebook{},
+		&models.Tag{},
+		&models.Note{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.OneTimeToken{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
+		&models.DirectoryRoleGrant{},
+		&models.OIDCLoginState{},
+		&models.DeviceAuthorization{},
+		&models.PersonalAccessToken{},
+		&models.LoginAttempt{},
+		&models.AuditEvent{},
+	)
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
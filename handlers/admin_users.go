@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/mailer"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+)
+
+// AdminUsersHandler handles user management by administrators
+type AdminUsersHandler struct {
+	mailer mailer.Mailer
+}
+
+// NewAdminUsersHandler creates a new admin users handler
+func NewAdminUsersHandler(mail mailer.Mailer) *AdminUsersHandler {
+	return &AdminUsersHandler{mailer: mail}
+}
+
+// GetUsers lists users with pagination, search and status filtering
+func (h *AdminUsersHandler) GetUsers(c *fiber.Ctx) error {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	// Parse status filter
+	status := c.Query("status", "")
+	switch status {
+	case "", models.UserStatusActive, models.UserStatusDisabled, models.UserStatusDeleted:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Status must be one of active, disabled or deleted",
+		})
+	}
+
+	// Fetch users
+	users, total, err := services.ListUsers(services.UserFilter{
+		Search:  c.Query("search", ""),
+		Status:  status,
+		Page:    page,
+		PerPage: perPage,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch users",
+		})
+	}
+
+	// Count notes of the listed users
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	noteCounts, err := services.CountNotesByUser(userIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count notes",
+		})
+	}
+
+	// Convert to response format
+	userResponses := make([]models.AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		userResponses = append(userResponses, user.ToAdminResponse(noteCounts[user.ID]))
+	}
+
+	// Build paginated response
+	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+	response := models.PaginatedUsersResponse{
+		Users:       userResponses,
+		Total:       total,
+		Page:        page,
+		PerPage:     perPage,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Users retrieved successfully",
+		"data":    response,
+	})
+}
+
+// GetUser retrieves a single user, including deleted ones
+func (h *AdminUsersHandler) GetUser(c *fiber.Ctx) error {
+	// Get user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Find user
+	user, err := services.GetUser(uint(userID))
+	if err != nil {
+		return adminUserErrorResponse(c, err)
+	}
+
+	return h.userResponse(c, user, "User retrieved successfully")
+}
+
+// DisableUser blocks a user from signing in and signs out their sessions
+func (h *AdminUsersHandler) DisableUser(c *fiber.Ctx) error {
+	// Get IDs of the administrator and the target user
+	actorID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Disable user
+	user, err := services.DisableUser(actorID, uint(userID))
+	if err != nil {
+		return adminUserErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditUserDisabled, user.ID, "")
+
+	return h.userResponse(c, user, "User disabled successfully")
+}
+
+// EnableUser allows a disabled user to sign in again
+func (h *AdminUsersHandler) EnableUser(c *fiber.Ctx) error {
+	// Get user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Enable user
+	user, err := services.EnableUser(uint(userID))
+	if err != nil {
+		return adminUserErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditUserEnabled, user.ID, "")
+
+	return h.userResponse(c, user, "User enabled successfully")
+}
+
+// ForcePasswordReset signs a user out and emails them a password reset link
+func (h *AdminUsersHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	// Get user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Require a password reset
+	user, err := services.ForcePasswordReset(uint(userID))
+	if err != nil {
+		return adminUserErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditPasswordResetForced, user.ID, "")
+
+	// Issue reset token
+	ttl := passwordResetTTL()
+	token, err := services.CreateOneTimeToken(user.ID, models.TokenPurposePasswordReset, ttl, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create password reset token",
+		})
+	}
+
+	// Send reset email
+	link := frontendURL("/reset-password", token)
+	if err := h.mailer.Send(mailer.PasswordResetMessage(user.Email, user.Name, link, ttl)); err != nil {
+		log.Println("Failed to send password reset email:", err)
+	}
+
+	return h.userResponse(c, user, "Password reset required and reset link sent")
+}
+
+// DeleteUser soft-deletes a user and signs out their sessions
+func (h *AdminUsersHandler) DeleteUser(c *fiber.Ctx) error {
+	// Get IDs of the administrator and the target user
+	actorID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Delete user
+	if err := services.DeleteUser(actorID, uint(userID)); err != nil {
+		return adminUserErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditUserDeleted, uint(userID), "")
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "User deleted successfully",
+	})
+}
+
+// RestoreUser restores a soft-deleted user
+func (h *AdminUsersHandler) RestoreUser(c *fiber.Ctx) error {
+	// Get user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Restore user
+	user, err := services.RestoreUser(uint(userID))
+	if err != nil {
+		return adminUserErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditUserRestored, user.ID, "")
+
+	return h.userResponse(c, user, "User restored successfully")
+}
+
+// userResponse responds with the user and their note count
+func (h *AdminUsersHandler) userResponse(c *fiber.Ctx, user *models.User, message string) error {
+	noteCounts, err := services.CountNotesByUser([]uint{user.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count notes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": message,
+		"data":    user.ToAdminResponse(noteCounts[user.ID]),
+	})
+}
+
+// adminUserErrorResponse maps user management errors to responses
+func adminUserErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	case services.ErrCannotManageSelf:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "You cannot disable or delete your own account",
+		})
+	case services.ErrUserNotDeleted:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "User is not deleted",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": "Failed to update user",
+	})
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+)
+
+// AuditHandler handles audit log queries
+type AuditHandler struct{}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// GetMyEvents lists audit events of the authenticated user's account
+func (h *AuditHandler) GetMyEvents(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Parse filters
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return invalidAuditFilterResponse(c)
+	}
+	filter.OwnerID = &userID
+
+	return h.listEvents(c, filter)
+}
+
+// GetEvents lists audit events of all users (admin only)
+func (h *AuditHandler) GetEvents(c *fiber.Ctx) error {
+	// Parse filters
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return invalidAuditFilterResponse(c)
+	}
+
+	if value := c.Query("user_id"); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return invalidAuditFilterResponse(c)
+		}
+		id := uint(userID)
+		filter.UserID = &id
+	}
+	if value := c.Query("actor_id"); value != "" {
+		actorID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return invalidAuditFilterResponse(c)
+		}
+		id := uint(actorID)
+		filter.ActorID = &id
+	}
+	filter.IPAddress = c.Query("ip")
+
+	return h.listEvents(c, filter)
+}
+
+// listEvents responds with a page of audit events matching the filter
+func (h *AuditHandler) listEvents(c *fiber.Ctx, filter services.AuditFilter) error {
+	// Fetch events
+	events, total, err := services.ListAuditEvents(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch audit events",
+		})
+	}
+
+	// Build paginated response
+	totalPages := int(math.Ceil(float64(total) / float64(filter.PerPage)))
+	response := models.PaginatedAuditEventsResponse{
+		Events:      events,
+		Total:       total,
+		Page:        filter.Page,
+		PerPage:     filter.PerPage,
+		TotalPages:  totalPages,
+		HasNext:     filter.Page < totalPages,
+		HasPrevious: filter.Page > 1,
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Audit events retrieved successfully",
+		"data":    response,
+	})
+}
+
+// parseAuditFilter reads the pagination, action, target and time range query parameters
+func parseAuditFilter(c *fiber.Ctx) (services.AuditFilter, bool) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	filter := services.AuditFilter{
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Page:       page,
+		PerPage:    perPage,
+	}
+
+	// Actions are comma separated
+	for _, action := range strings.Split(c.Query("action"), ",") {
+		if action = strings.TrimSpace(action); action != "" {
+			filter.Actions = append(filter.Actions, action)
+		}
+	}
+
+	// Time range uses RFC 3339 timestamps
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, false
+		}
+		*target = &parsed
+	}
+
+	return filter, true
+}
+
+// invalidAuditFilterResponse rejects malformed audit query parameters
+func invalidAuditFilterResponse(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":   true,
+		"message": "Invalid filter; ids must be numeric and from/to RFC 3339 timestamps",
+	})
+}
+
+// recordAudit appends an event for the current request to the audit log. The actor defaults
+// to the authenticated user; failures are logged so auditing never breaks the request.
+func recordAudit(c *fiber.Ctx, event models.AuditEvent) {
+	if event.ActorID == nil {
+		if userID, ok := c.Locals("userID").(uint); ok {
+			event.ActorID = &userID
+		}
+	}
+	event.IPAddress = c.IP()
+	event.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	if err := services.RecordAuditEvent(&event); err != nil {
+		log.Println("Failed to record audit event:", err)
+	}
+}
+
+// auditTargetID formats a numeric resource ID for an audit event
+func auditTargetID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// recordAdminAudit appends an event for an administrator's action on another user's account
+func recordAdminAudit(c *fiber.Ctx, action string, userID uint, details string) {
+	recordAudit(c, models.AuditEvent{
+		Action:     action,
+		UserID:     &userID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditTargetID(userID),
+		Details:    details,
+	})
+}
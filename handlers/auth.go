@@ -1,20 +1,37 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes-api/authbackend"
 	"notes-api/config"
+	"notes-api/mailer"
+	"notes-api/middleware"
 	"notes-api/models"
+	"notes-api/oidc"
+	"notes-api/services"
 	"notes-api/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // AuthHandler handles authentication-related operations
-type AuthHandler struct{}
+type AuthHandler struct {
+	mailer    mailer.Mailer
+	providers map[string]*oidc.Provider
+	throttle  *services.LoginThrottle
+	backends  []authbackend.Backend
+}
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler() *AuthHandler {
-	return &AuthHandler{}
+// NewAuthHandler creates a new auth handler; password logins try the backends in order
+func NewAuthHandler(mail mailer.Mailer, providers map[string]*oidc.Provider, throttle *services.LoginThrottle, backends []authbackend.Backend) *AuthHandler {
+	return &AuthHandler{mailer: mail, providers: providers, throttle: throttle, backends: backends}
 }
 
 // Register handles user registration
@@ -47,6 +64,24 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Check the workspace invitation before creating the account
+	var invitation *models.WorkspaceInvitation
+	if req.InvitationToken != "" {
+		var err error
+		if invitation, err = services.FindWorkspaceInvitation(req.InvitationToken); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid or expired invitation",
+			})
+		}
+		if !strings.EqualFold(invitation.Email, req.Email) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Register with the email address the invitation was sent to",
+			})
+		}
+	}
+
 	// Create new user
 	user := models.User{
 		Name:     req.Name,
@@ -54,6 +89,12 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		Password: req.Password, // Will be hashed by BeforeCreate hook
 	}
 
+	// The invitation link already proved control of the address
+	if invitation != nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
 	// Save user to database
 	if err := config.GetDB().Create(&user).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -62,8 +103,21 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	recordAudit(c, models.AuditEvent{Action: models.AuditUserRegistered, ActorID: &user.ID, Email: user.Email})
+
+	// Join the invited workspace, or send an email verification link
+	if invitation != nil {
+		if _, accepted, err := services.AcceptWorkspaceInvitation(&user, req.InvitationToken); err != nil {
+			log.Println("Failed to accept workspace invitation:", err)
+		} else {
+			recordInvitationAudit(c, models.AuditInvitationAccepted, accepted)
+		}
+	} else if err := h.sendVerificationEmail(&user); err != nil {
+		log.Println("Failed to send verification email:", err)
+	}
+
+	// Issue access and refresh tokens
+	tokens, err := services.IssueTokenPair(&user, sessionMetaFromContext(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
@@ -71,20 +125,12 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"error":   false,
-		"message": "User registered successfully",
-		"data": fiber.Map{
-			"user":  user.ToResponse(),
-			"token": token,
-		},
-	})
+	return respondWithTokens(c, fiber.StatusCreated, "User registered successfully", &user, tokens)
 }
 
 // Login handles user login
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var req models.UserLoginRequest
-log.Println(c.BodyParser(&req),"body")
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -102,39 +148,420 @@ log.Println(c.BodyParser(&req),"body")
 		})
 	}
 
+	// Slow down repeated failures for the account and the client IP
+	throttleKeys := services.LoginKeys(req.Email, c.IP())
+	if retryAfter, err := h.throttle.RetryAfter(throttleKeys...); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check login attempts",
+		})
+	} else if retryAfter > 0 {
+		return tooManyLoginAttemptsResponse(c, retryAfter)
+	}
+
+	// Check credentials with the authentication backends
+	user, err := h.authenticate(req.Email, req.Password)
+	switch err {
+	case nil:
+	case authbackend.ErrInvalidCredentials:
+		// Attribute the failure to the account if it exists locally
+		var existing models.User
+		if err := config.GetDB().Where("email = ?", req.Email).First(&existing).Error; err != nil {
+			return h.loginFailed(c, req.Email, nil, throttleKeys)
+		}
+		return h.loginFailed(c, req.Email, &existing, throttleKeys)
+	case authbackend.ErrPasswordResetRequired:
+		// Administrators can require a new password before the old one is accepted again
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "A password reset is required; check your email for a reset link",
+		})
+	case authbackend.ErrAccountConflict:
+		// A local account already uses the directory account's email address
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "An account with this email address already exists; sign in with its own password",
+		})
+	case authbackend.ErrAccountDeleted:
+		// The directory account's local account has been deleted
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "This account has been deleted",
+		})
+	default:
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "Authentication service is unavailable",
+		})
+	}
+
+	return h.completeLogin(c, user)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+
+	// Parse optional request body
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	// Cookie sessions send the refresh token as a cookie, guarded by the CSRF token
+	if req.RefreshToken == "" {
+		if cookie := c.Cookies(refreshTokenCookie); cookie != "" {
+			session, err := services.FindRefreshTokenSession(cookie)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error":   true,
+					"message": "Invalid or expired refresh token",
+				})
+			}
+			if !middleware.VerifyCSRF(c, session.ID) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error":   true,
+					"message": "Invalid CSRF token",
+				})
+			}
+			req.RefreshToken = cookie
+			c.Locals("cookieSession", true)
+		}
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Rotate refresh token
+	user, tokens, err := services.RotateRefreshToken(req.RefreshToken)
+	if err != nil {
+		if err == services.ErrInvalidRefreshToken || err == services.ErrRefreshTokenReused {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid or expired refresh token",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to refresh token",
+		})
+	}
+
+	return respondWithTokens(c, fiber.StatusOK, "Token refreshed successfully", user, tokens)
+}
+
+// Logout revokes the current access token and, if provided, its refresh token family
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req models.LogoutRequest
+
+	// Parse optional request body
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	// Get token claims from context
+	claims, err := middleware.GetClaimsFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Revoke the access token until it would have expired anyway
+	if err := services.Revocations.Revoke(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke token",
+		})
+	}
+
+	// Sign out the session so it can no longer be refreshed
+	if err := services.RevokeSession(claims.UserID, claims.SessionID); err != nil && err != services.ErrSessionNotFound {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke session",
+		})
+	}
+
+	// Revoke the refresh token family of a cookie session or an explicitly provided one as well
+	if req.RefreshToken == "" {
+		req.RefreshToken = c.Cookies(refreshTokenCookie)
+	}
+	if req.RefreshToken != "" {
+		if err := services.RevokeRefreshToken(claims.UserID, req.RefreshToken); err != nil && err != services.ErrInvalidRefreshToken {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to revoke refresh token",
+			})
+		}
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditLogout,
+		TargetType: models.AuditTargetSession,
+		TargetID:   auditTargetID(claims.SessionID),
+	})
+
+	if middleware.IsCookieSession(c) {
+		clearSessionCookies(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Logged out successfully",
+	})
+}
+
+// ForgotPassword emails a password reset link if an account exists for the address
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req models.ForgotPasswordRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Always answer the same way so the endpoint cannot be used to discover accounts
+	response := fiber.Map{
+		"error":   false,
+		"message": "If an account exists for this email, a password reset link has been sent",
+	}
+
 	// Find user by email
 	var user models.User
 	if err := config.GetDB().Where("email = ?", req.Email).First(&user).Error; err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		return c.JSON(response)
+	}
+
+	// Issue reset token
+	ttl := passwordResetTTL()
+	token, err := services.CreateOneTimeToken(user.ID, models.TokenPurposePasswordReset, ttl, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
-			"message": "Invalid email or password",
+			"message": "Failed to create password reset token",
 		})
 	}
 
-	// Check password
-	if !user.CheckPassword(req.Password) {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+	recordAudit(c, models.AuditEvent{Action: models.AuditPasswordResetRequested, UserID: &user.ID, Email: user.Email})
+
+	// Send reset email
+	link := frontendURL("/reset-password", token)
+	if err := h.mailer.Send(mailer.PasswordResetMessage(user.Email, user.Name, link, ttl)); err != nil {
+		log.Println("Failed to send password reset email:", err)
+	}
+
+	return c.JSON(response)
+}
+
+// ResetPassword sets a new password using a reset token and signs out all sessions
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
-			"message": "Invalid email or password",
+			"message": "Invalid request body",
 		})
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Look up reset token; it is only redeemed together with the password update
+	token, err := services.FindOneTimeToken(models.TokenPurposePasswordReset, req.Token)
 	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired reset token",
+		})
+	}
+
+	// Find user
+	var user models.User
+	if err := config.GetDB().First(&user, token.UserID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired reset token",
+		})
+	}
+
+	// Reject passwords resembling the account's email address
+	if message := utils.CheckPasswordPolicy(req.Password, user.Email); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  utils.ValidationErrors{{Field: "password", Message: message}},
+		})
+	}
+
+	// Redeem the token, update the password (also invalidates issued access tokens) and sign out every session
+	if err := services.ResetPassword(token, &user, req.Password); err != nil {
+		if err == services.ErrInvalidOneTimeToken {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid or expired reset token",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
-			"message": "Failed to generate token",
+			"message": "Failed to update password",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditPasswordReset, ActorID: &user.ID})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Password reset successfully",
+	})
+}
+
+// VerifyEmail confirms the user's email address using a verification token
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req models.VerifyEmailRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Redeem verification token
+	token, err := services.ConsumeOneTimeToken(models.TokenPurposeEmailVerification, req.Token)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired verification token",
+		})
+	}
+
+	// Find user
+	var user models.User
+	if err := config.GetDB().First(&user, token.UserID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired verification token",
+		})
+	}
+
+	// Ignore links sent for an address the user has since moved away from
+	if token.Payload != user.Email {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired verification token",
+		})
+	}
+
+	// Mark email as verified
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := config.GetDB().Model(&user).Update("email_verified_at", now).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to verify email",
+			})
+		}
+		services.Users.Invalidate(user.ID)
+		recordAudit(c, models.AuditEvent{Action: models.AuditEmailVerified, ActorID: &user.ID, Email: user.Email})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Email verified successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// ResendVerification sends a new verification link to the authenticated user
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if user.IsEmailVerified() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Email is already verified",
+		})
+	}
+
+	// Throttle resends per user
+	lastSentAt, err := services.LastOneTimeTokenIssuedAt(user.ID, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to send verification email",
+		})
+	}
+	if lastSentAt != nil {
+		if wait := time.Until(lastSentAt.Add(verificationResendCooldown())); wait > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   true,
+				"message": "Please wait before requesting another verification email",
+			})
+		}
+	}
+
+	// Send email verification link
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Println("Failed to send verification email:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to send verification email",
 		})
 	}
 
 	return c.JSON(fiber.Map{
 		"error":   false,
-		"message": "Login successful",
-		"data": fiber.Map{
-			"user":  user.ToResponse(),
-			"token": token,
-		},
+		"message": "Verification email sent",
 	})
 }
 
@@ -155,3 +582,210 @@ func (h *AuthHandler) Profile(c *fiber.Ctx) error {
 		"data":    user.ToResponse(),
 	})
 }
+
+// authenticate tries the authentication backends in order and returns the first user one accepts.
+// Backend failures are logged and only reported when no backend accepted the credentials.
+func (h *AuthHandler) authenticate(email, password string) (*models.User, error) {
+	var failure error
+	for _, backend := range h.backends {
+		user, err := backend.Authenticate(email, password)
+		switch err {
+		case nil:
+			return user, nil
+		case authbackend.ErrInvalidCredentials:
+		case authbackend.ErrPasswordResetRequired:
+			return nil, err
+		case authbackend.ErrAccountConflict, authbackend.ErrAccountDeleted:
+			// Reported unless another backend accepts the credentials
+			failure = err
+		default:
+			log.Printf("Auth backend %s failed: %v", backend.Name(), err)
+			failure = err
+		}
+	}
+
+	if failure != nil {
+		return nil, failure
+	}
+	return nil, authbackend.ErrInvalidCredentials
+}
+
+// completeLogin finishes a successful first-factor login. Users with two-factor
+// authentication get an "mfa pending" token instead of access and refresh tokens.
+func (h *AuthHandler) completeLogin(c *fiber.Ctx, user *models.User) error {
+	if user.IsDisabled() {
+		return accountDisabledResponse(c)
+	}
+
+	if user.IsTwoFactorEnabled() {
+		mfaToken, err := utils.GenerateMFAToken(user.ID, user.Email)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to generate token",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"error":   false,
+			"message": "Two-factor authentication required",
+			"data": fiber.Map{
+				"mfa_required": true,
+				"mfa_token":    mfaToken,
+				"expires_in":   int64(utils.MFATokenTTL().Seconds()),
+			},
+		})
+	}
+
+	return h.issueLoginTokens(c, user)
+}
+
+// loginFailed counts a failed login, audits it together with any resulting lockout and rejects the attempt
+func (h *AuthHandler) loginFailed(c *fiber.Ctx, email string, user *models.User, throttleKeys []string) error {
+	failures, err := h.throttle.RecordFailure(throttleKeys...)
+	if err != nil {
+		log.Println("Failed to record login attempt:", err)
+	}
+
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	recordAudit(c, models.AuditEvent{Action: models.AuditLoginFailed, UserID: userID, Email: email})
+
+	for _, failure := range failures {
+		if !failure.Locked {
+			continue
+		}
+
+		event := models.AuditEvent{
+			Action:  models.AuditAccountLocked,
+			UserID:  userID,
+			Email:   email,
+			Details: fmt.Sprintf("locked after %d failed attempts", failure.Failures),
+		}
+		if services.IsIPLoginKey(failure.Key) {
+			event.Action = models.AuditIPLocked
+		}
+		recordAudit(c, event)
+	}
+
+	// Tell well-behaved clients when their next attempt will be evaluated
+	if retryAfter, err := h.throttle.RetryAfter(throttleKeys...); err == nil && retryAfter > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error":   true,
+		"message": "Invalid email or password",
+	})
+}
+
+// tooManyLoginAttemptsResponse rejects an attempt made before the backoff or lockout expired
+func tooManyLoginAttemptsResponse(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       true,
+		"message":     "Too many failed login attempts, please try again later",
+		"retry_after": seconds,
+	})
+}
+
+// accountDisabledResponse rejects a login to a disabled account
+func accountDisabledResponse(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error":   true,
+		"message": "Account is disabled",
+	})
+}
+
+// issueLoginTokens starts a new session and responds with its tokens
+func (h *AuthHandler) issueLoginTokens(c *fiber.Ctx, user *models.User) error {
+	tokens, err := h.startLoginSession(c, user, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	return respondWithTokens(c, fiber.StatusOK, "Login successful", user, tokens)
+}
+
+// startLoginSession does the bookkeeping every successful login shares and issues the new session's tokens.
+// Failures are returned as fiber errors carrying a message for the client.
+func (h *AuthHandler) startLoginSession(c *fiber.Ctx, user *models.User, details string) (*services.TokenPair, error) {
+	// A completed login clears the account's failed attempts
+	if err := h.throttle.Reset(services.AccountLoginKey(user.Email)); err != nil {
+		log.Println("Failed to reset login attempts:", err)
+	}
+
+	// Signing in during the grace period cancels a scheduled account deletion
+	if err := services.CancelAccountDeletion(user); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to cancel account deletion")
+	}
+
+	// Issue access and refresh tokens
+	tokens, err := services.IssueTokenPair(user, sessionMetaFromContext(c))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditLoginSucceeded, ActorID: &user.ID, Email: user.Email, Details: details})
+
+	return tokens, nil
+}
+
+// tokenResponseData builds the response payload returned whenever tokens are issued
+func tokenResponseData(user *models.User, tokens *services.TokenPair) fiber.Map {
+	return fiber.Map{
+		"user":          user.ToResponse(),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	}
+}
+
+// sessionMetaFromContext describes the client making the request
+func sessionMetaFromContext(c *fiber.Ctx) services.SessionMeta {
+	return services.SessionMeta{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IPAddress: c.IP(),
+	}
+}
+
+// sendVerificationEmail issues a verification token bound to the user's current address and mails it
+func (h *AuthHandler) sendVerificationEmail(user *models.User) error {
+	ttl := utils.GetEnvDuration("EMAIL_VERIFICATION_EXPIRATION_HOURS", time.Hour, 24*time.Hour)
+	token, err := services.CreateOneTimeToken(user.ID, models.TokenPurposeEmailVerification, ttl, user.Email)
+	if err != nil {
+		return err
+	}
+
+	link := frontendURL("/verify-email", token)
+	return h.mailer.Send(mailer.EmailVerificationMessage(user.Email, user.Name, link, ttl))
+}
+
+// verificationResendCooldown returns the minimum time between verification emails (default: 60 seconds)
+func verificationResendCooldown() time.Duration {
+	return utils.GetEnvDuration("EMAIL_VERIFICATION_RESEND_SECONDS", time.Second, time.Minute)
+}
+
+// passwordResetTTL returns how long password reset links stay valid (default: 30 minutes)
+func passwordResetTTL() time.Duration {
+	return utils.GetEnvDuration("PASSWORD_RESET_EXPIRATION_MINUTES", time.Minute, 30*time.Minute)
+}
+
+// frontendURL builds a link into the web frontend carrying a token
+func frontendURL(path, token string) string {
+	return utils.GetEnv("APP_BASE_URL", "http://localhost:3000") + path + "?token=" + url.QueryEscape(token)
+}
+
+// secureCookies reports whether cookies should carry the Secure attribute. The request protocol is
+// not trusted for this as it reads http behind a TLS terminating proxy, so cookies are Secure
+// unless COOKIE_SECURE=false opts out for local development over plain HTTP.
+func secureCookies() bool {
+	return utils.GetEnvBool("COOKIE_SECURE", true)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+const (
+	// refreshTokenCookie holds the refresh token of a cookie session; it is only sent to the auth routes
+	refreshTokenCookie = "refresh_token"
+
+	// authModeHeader lets browser clients ask for a cookie session instead of tokens in the body
+	authModeHeader = "X-Auth-Mode"
+
+	// authModeCookie is the X-Auth-Mode value that selects cookie sessions
+	authModeCookie = "cookie"
+)
+
+// wantsCookieSession reports whether tokens should be delivered as cookies:
+// the client asked for it, or it is already using a cookie session
+func wantsCookieSession(c *fiber.Ctx) bool {
+	if middleware.IsCookieSession(c) {
+		return true
+	}
+	return strings.EqualFold(c.Get(authModeHeader), authModeCookie) ||
+		c.Cookies(oidcAuthModeCookie) == authModeCookie
+}
+
+// respondWithTokens sends newly issued tokens either in the response body or, for
+// cookie sessions, as HttpOnly cookies together with a fresh CSRF token
+func respondWithTokens(c *fiber.Ctx, status int, message string, user *models.User, tokens *services.TokenPair) error {
+	if !wantsCookieSession(c) {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   false,
+			"message": message,
+			"data":    tokenResponseData(user, tokens),
+		})
+	}
+
+	// Derive the CSRF token bound to the session
+	csrfToken := utils.CSRFToken(tokens.SessionID)
+
+	setSessionCookies(c, tokens, csrfToken)
+
+	return c.Status(status).JSON(fiber.Map{
+		"error":   false,
+		"message": message,
+		"data": fiber.Map{
+			"user":       user.ToResponse(),
+			"token_type": "cookie",
+			"expires_in": tokens.ExpiresIn,
+			"csrf_token": csrfToken,
+		},
+	})
+}
+
+// setSessionCookies stores the token pair and the CSRF token in the browser
+func setSessionCookies(c *fiber.Ctx, tokens *services.TokenPair, csrfToken string) {
+	now := time.Now()
+
+	c.Cookie(sessionCookie(middleware.AccessTokenCookie, tokens.AccessToken, "/api/v1", now.Add(utils.AccessTokenTTL()), true))
+	c.Cookie(sessionCookie(refreshTokenCookie, tokens.RefreshToken, "/api/v1/auth", now.Add(services.RefreshTokenTTL()), true))
+
+	// The CSRF cookie must be readable by the frontend so it can echo it in the X-CSRF-Token header
+	c.Cookie(sessionCookie(middleware.CSRFCookie, csrfToken, "/", now.Add(services.RefreshTokenTTL()), false))
+}
+
+// clearSessionCookies removes the cookies of a cookie session
+func clearSessionCookies(c *fiber.Ctx) {
+	expired := time.Unix(0, 0)
+
+	c.Cookie(sessionCookie(middleware.AccessTokenCookie, "", "/api/v1", expired, true))
+	c.Cookie(sessionCookie(refreshTokenCookie, "", "/api/v1/auth", expired, true))
+	c.Cookie(sessionCookie(middleware.CSRFCookie, "", "/", expired, false))
+}
+
+// sessionCookie builds a cookie carrying the session attributes shared by all session cookies
+func sessionCookie(name, value, path string, expires time.Time, httpOnly bool) *fiber.Cookie {
+	sameSite := cookieSameSite()
+
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Expires:  expires,
+		HTTPOnly: httpOnly,
+		// Browsers drop SameSite=None cookies that are not Secure
+		Secure:   secureCookies() || sameSite == fiber.CookieSameSiteNoneMode,
+		SameSite: sameSite,
+	}
+}
+
+// cookieSameSite returns the SameSite attribute of session cookies (default: Lax)
+func cookieSameSite() string {
+	switch strings.ToLower(utils.GetEnv("COOKIE_SAMESITE", fiber.CookieSameSiteLaxMode)) {
+	case "strict":
+		return fiber.CookieSameSiteStrictMode
+	case "none":
+		return fiber.CookieSameSiteNoneMode
+	default:
+		return fiber.CookieSameSiteLaxMode
+	}
+}
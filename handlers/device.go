@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// The device code and token endpoints are called by OAuth clients, so unlike the rest of
+// the API they answer with the plain RFC 8628 / RFC 6749 response and error formats.
+
+// DeviceCode starts the device authorization grant for a client without a browser
+func (h *AuthHandler) DeviceCode(c *fiber.Ctx) error {
+	var req models.DeviceCodeRequest
+
+	// Parse optional request body (form or JSON encoded)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return deviceTokenError(c, fiber.StatusBadRequest, "invalid_request", "Invalid request body")
+		}
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return deviceTokenError(c, fiber.StatusBadRequest, "invalid_request", errors[0].Field+" "+errors[0].Message)
+	}
+
+	// Create device and user codes
+	authorization, deviceCode, err := services.CreateDeviceAuthorization(req.ClientID)
+	if err != nil {
+		return deviceTokenError(c, fiber.StatusInternalServerError, "server_error", "Failed to create device code")
+	}
+
+	verificationURI := utils.GetEnv("APP_BASE_URL", "http://localhost:3000") + "/device"
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(models.DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(authorization.UserCode),
+		ExpiresIn:               int64(services.DeviceCodeTTL().Seconds()),
+		Interval:                authorization.Interval,
+	})
+}
+
+// DeviceToken is polled by the device until the user approved or denied it
+func (h *AuthHandler) DeviceToken(c *fiber.Ctx) error {
+	var req models.DeviceTokenRequest
+
+	// Parse request body (form or JSON encoded)
+	if err := c.BodyParser(&req); err != nil {
+		return deviceTokenError(c, fiber.StatusBadRequest, "invalid_request", "Invalid request body")
+	}
+
+	// Validate request
+	if req.GrantType != models.DeviceCodeGrantType {
+		return deviceTokenError(c, fiber.StatusBadRequest, "unsupported_grant_type", "grant_type must be "+models.DeviceCodeGrantType)
+	}
+	if req.DeviceCode == "" {
+		return deviceTokenError(c, fiber.StatusBadRequest, "invalid_request", "device_code is required")
+	}
+
+	// Check the authorization
+	user, authorization, err := services.PollDeviceAuthorization(req.DeviceCode, req.ClientID)
+	if err != nil {
+		switch err {
+		case services.ErrDeviceAuthorizationPending:
+			return deviceTokenError(c, fiber.StatusBadRequest, "authorization_pending", "The user has not yet approved this device")
+		case services.ErrDeviceSlowDown:
+			return deviceTokenError(c, fiber.StatusBadRequest, "slow_down", "Polling too fast; wait 5 more seconds between requests")
+		case services.ErrDeviceAccessDenied:
+			return deviceTokenError(c, fiber.StatusBadRequest, "access_denied", "The user denied this device")
+		case services.ErrDeviceCodeExpired:
+			return deviceTokenError(c, fiber.StatusBadRequest, "expired_token", "The device code has expired")
+		case services.ErrInvalidDeviceCode:
+			return deviceTokenError(c, fiber.StatusBadRequest, "invalid_grant", "Invalid device code")
+		}
+		return deviceTokenError(c, fiber.StatusInternalServerError, "server_error", "Failed to check device code")
+	}
+
+	// The account may have been disabled since the device was approved
+	if user.IsDisabled() {
+		return deviceTokenError(c, fiber.StatusBadRequest, "access_denied", "Account is disabled")
+	}
+
+	// Start the session like any other login
+	tokens, err := h.startLoginSession(c, user, "device authorization "+authorization.UserCode)
+	if err != nil {
+		return deviceTokenError(c, fiber.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	// The RFC 6749 token response, carrying the user like the other login responses
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{
+		"access_token":  tokens.AccessToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"refresh_token": tokens.RefreshToken,
+		"user":          user.ToResponse(),
+	})
+}
+
+// GetDevice shows the signed-in user which device is asking for access
+func (h *AuthHandler) GetDevice(c *fiber.Ctx) error {
+	authorization, err := services.FindPendingDeviceAuthorization(c.Query("user_code"))
+	if err != nil {
+		return userCodeNotFoundResponse(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Device retrieved successfully",
+		"data":    authorization.ToResponse(),
+	})
+}
+
+// ApproveDevice lets the device sign in as the current user
+func (h *AuthHandler) ApproveDevice(c *fiber.Ctx) error {
+	return h.answerDevice(c, true)
+}
+
+// DenyDevice rejects the device
+func (h *AuthHandler) DenyDevice(c *fiber.Ctx) error {
+	return h.answerDevice(c, false)
+}
+
+// answerDevice records the current user's decision on a pending device
+func (h *AuthHandler) answerDevice(c *fiber.Ctx, approve bool) error {
+	var req models.DeviceApprovalRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Record decision
+	answer := services.DenyDeviceAuthorization
+	action, message := models.AuditDeviceDenied, "Device denied"
+	if approve {
+		answer = services.ApproveDeviceAuthorization
+		action, message = models.AuditDeviceApproved, "Device approved"
+	}
+
+	authorization, err := answer(req.UserCode, userID)
+	if err != nil {
+		if err == services.ErrUserCodeNotFound {
+			return userCodeNotFoundResponse(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update device",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:  action,
+		Details: fmt.Sprintf("user code %s, client %q", authorization.UserCode, authorization.ClientID),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": message,
+		"data":    authorization.ToResponse(),
+	})
+}
+
+// userCodeNotFoundResponse rejects unknown, expired or already answered user codes
+func userCodeNotFoundResponse(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error":   true,
+		"message": "Invalid or expired user code",
+	})
+}
+
+// deviceTokenError answers a device grant request with an RFC 6749 error response
+func deviceTokenError(c *fiber.Ctx, status int, code, description string) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(fiber.Map{
+		"error":             code,
+		"error_description": description,
+	})
+}
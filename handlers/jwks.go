@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"notes-api/utils"
+)
+
+// JWKS publishes the public keys that verify access tokens so other services need no shared secret
+func JWKS(c *fiber.Ctx) error {
+	// Load signing keys
+	ring, err := utils.LoadKeyRing()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Signing keys are not configured",
+		})
+	}
+
+	// Let verifiers cache the set briefly; rotated keys stay listed while they can still verify tokens
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(ring.JWKS())
+}
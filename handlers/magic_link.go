@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/config"
+	"notes-api/mailer"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+const (
+	// magicLinkNonceCookie binds a sign-in link to the browser that requested it
+	magicLinkNonceCookie = "magic_link_nonce"
+
+	// magicLinkCookiePath limits the nonce cookie to the magic link routes
+	magicLinkCookiePath = "/api/v1/auth/magic-link"
+)
+
+// RequestMagicLink emails a single-use sign-in link if an account exists for the address
+func (h *AuthHandler) RequestMagicLink(c *fiber.Ctx) error {
+	var req models.MagicLinkRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Always answer the same way so the endpoint cannot be used to discover accounts
+	response := fiber.Map{
+		"error":   false,
+		"message": "If an account exists for this email, a sign-in link has been sent",
+	}
+
+	// Bind the link to this browser; the cookie is set whether or not the account exists
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create sign-in link",
+		})
+	}
+	ttl := services.MagicLinkTTL()
+	c.Cookie(sessionCookie(magicLinkNonceCookie, nonce, magicLinkCookiePath, time.Now().Add(ttl), true))
+
+	// Find user by email
+	var user models.User
+	if err := config.GetDB().Where("email = ?", req.Email).First(&user).Error; err != nil || user.IsDisabled() {
+		return c.JSON(response)
+	}
+
+	// Issue sign-in token
+	token, err := services.CreateMagicLink(user.ID, nonce)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create sign-in link",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditMagicLinkRequested, UserID: &user.ID, Email: user.Email})
+
+	// Send sign-in email
+	link := frontendURL("/magic-link", token)
+	if err := h.mailer.Send(mailer.MagicLinkMessage(user.Email, user.Name, link, ttl)); err != nil {
+		log.Println("Failed to send sign-in link email:", err)
+	}
+
+	return c.JSON(response)
+}
+
+// VerifyMagicLink signs in with a sign-in link opened in the browser that requested it
+func (h *AuthHandler) VerifyMagicLink(c *fiber.Ctx) error {
+	var req models.MagicLinkVerifyRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Redeem token
+	user, err := services.RedeemMagicLink(req.Token, c.Cookies(magicLinkNonceCookie))
+	if err != nil {
+		switch err {
+		case services.ErrMagicLinkWrongBrowser:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Open the sign-in link in the browser where you requested it",
+			})
+		case services.ErrInvalidOneTimeToken:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid or expired sign-in link",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to verify sign-in link",
+		})
+	}
+
+	// The nonce is single-use as well
+	c.Cookie(sessionCookie(magicLinkNonceCookie, "", magicLinkCookiePath, time.Unix(0, 0), true))
+
+	return h.completeLogin(c, user)
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// NotebooksHandler handles notebook (nested folder) operations
+type NotebooksHandler struct{}
+
+// NewNotebooksHandler creates a new notebooks handler
+func NewNotebooksHandler() *NotebooksHandler {
+	return &NotebooksHandler{}
+}
+
+// CreateNotebook creates a notebook in the personal or addressed workspace
+func (h *NotebooksHandler) CreateNotebook(c *fiber.Ctx) error {
+	var req models.NotebookCreateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Resolve the workspace the notebooks belong to
+	scope, err := noteScope(c)
+	if err != nil {
+		return noteScopeErrorResponse(c, err)
+	}
+
+	// Create notebook
+	notebook, err := services.CreateNotebook(scope, req.Name, req.ParentID)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNotebookCreated,
+		TargetType: models.AuditTargetNotebook,
+		TargetID:   auditTargetID(notebook.ID),
+		Details:    scope.AuditDetails(),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Notebook created successfully",
+		"data":    notebook.ToResponse(0),
+	})
+}
+
+// GetNotebooks lists the notebooks of the workspace as a tree
+func (h *NotebooksHandler) GetNotebooks(c *fiber.Ctx) error {
+	// Resolve the workspace the notebooks belong to
+	scope, err := noteScope(c)
+	if err != nil {
+		return noteScopeErrorResponse(c, err)
+	}
+
+	// Fetch notebooks
+	notebooks, err := services.ListNotebooks(scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch notebooks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Notebooks retrieved successfully",
+		"data":    notebooks,
+	})
+}
+
+// GetNotebook retrieves a notebook with its nested notebooks
+func (h *NotebooksHandler) GetNotebook(c *fiber.Ctx) error {
+	scope, notebook, err := notebookFromRequest(c)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	// Build the notebook's subtree
+	response, err := services.GetNotebookTree(scope, notebook)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Notebook retrieved successfully",
+		"data":    response,
+	})
+}
+
+// RenameNotebook renames a notebook (own notebooks, or any workspace notebook for owners and admins)
+func (h *NotebooksHandler) RenameNotebook(c *fiber.Ctx) error {
+	var req models.NotebookUpdateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	scope, notebook, err := modifiableNotebookFromRequest(c)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	// Rename notebook
+	if err := services.RenameNotebook(notebook, req.Name); err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNotebookUpdated,
+		TargetType: models.AuditTargetNotebook,
+		TargetID:   auditTargetID(notebook.ID),
+		Details:    scope.AuditDetails(),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Notebook renamed successfully",
+		"data":    notebook.ToResponse(0),
+	})
+}
+
+// MoveNotebook moves a notebook and everything below it under another notebook or to the top level
+func (h *NotebooksHandler) MoveNotebook(c *fiber.Ctx) error {
+	var req models.NotebookMoveRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	scope, notebook, err := modifiableNotebookFromRequest(c)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	// Move notebook
+	if err := services.MoveNotebook(scope, notebook, req.ParentID); err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNotebookMoved,
+		TargetType: models.AuditTargetNotebook,
+		TargetID:   auditTargetID(notebook.ID),
+		Details:    joinAuditDetails(scope.AuditDetails(), notebookDestination("parent", notebook.ParentID)),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Notebook moved successfully",
+		"data":    notebook.ToResponse(0),
+	})
+}
+
+// DeleteNotebook deletes a notebook and its nested notebooks. With ?notes=delete their notes are
+// deleted too; by default (?notes=move) nested notebooks and notes move up to the parent notebook.
+func (h *NotebooksHandler) DeleteNotebook(c *fiber.Ctx) error {
+	mode := c.Query("notes", "move")
+	if mode != "move" && mode != "delete" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "notes must be move or delete",
+		})
+	}
+
+	scope, notebook, err := modifiableNotebookFromRequest(c)
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	// Delete notebook
+	affected, err := services.DeleteNotebook(scope, notebook, mode == "delete")
+	if err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	details := notebookDestination(fmt.Sprintf("moved %d notes to", affected), notebook.ParentID)
+	if mode == "delete" {
+		details = fmt.Sprintf("deleted %d notes", affected)
+	}
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNotebookDeleted,
+		TargetType: models.AuditTargetNotebook,
+		TargetID:   auditTargetID(notebook.ID),
+		Details:    joinAuditDetails(scope.AuditDetails(), details),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Notebook deleted successfully",
+	})
+}
+
+// notebookFromRequest resolves the workspace and finds the notebook addressed by the route
+func notebookFromRequest(c *fiber.Ctx) (*services.NoteScope, *models.Notebook, error) {
+	// Get notebook ID from URL parameter
+	notebookID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, nil, services.ErrNotebookNotFound
+	}
+
+	// Resolve the workspace the notebooks belong to
+	scope, err := noteScope(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Find notebook
+	notebook, err := scope.FindNotebook(uint(notebookID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return scope, notebook, nil
+}
+
+// modifiableNotebookFromRequest is notebookFromRequest for notebooks the user is about to change
+func modifiableNotebookFromRequest(c *fiber.Ctx) (*services.NoteScope, *models.Notebook, error) {
+	scope, notebook, err := notebookFromRequest(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Members may only change other people's notebooks with an owner or admin role
+	if !scope.CanModifyNotebook(notebook) {
+		return nil, nil, services.ErrWorkspaceForbidden
+	}
+	return scope, notebook, nil
+}
+
+// notebookDestination describes where something was moved for audit events
+func notebookDestination(prefix string, notebookID *uint) string {
+	if notebookID == nil {
+		return prefix + " top level"
+	}
+	return fmt.Sprintf("%s notebook %d", prefix, *notebookID)
+}
+
+// joinAuditDetails combines audit details, skipping empty parts
+func joinAuditDetails(parts ...string) string {
+	details := ""
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if details != "" {
+			details += ", "
+		}
+		details += part
+	}
+	return details
+}
+
+// notebookErrorResponse maps notebook errors to responses
+func notebookErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrWorkspaceNotFound:
+		return noteScopeErrorResponse(c, err)
+	case services.ErrNotebookNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Notebook not found",
+		})
+	case services.ErrNotebookCycle:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "A notebook cannot be moved into itself or one of its nested notebooks",
+		})
+	case services.ErrWorkspaceForbidden:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "You can only change your own notebooks and notes in this workspace",
+		})
+	}
+
+	if fiberErr, ok := err.(*fiber.Error); ok {
+		return fiberErr
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": "Failed to update notebook",
+	})
+}
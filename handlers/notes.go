@@ -6,9 +6,11 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	"notes-api/config"
 	"notes-api/middleware"
 	"notes-api/models"
+	"notes-api/services"
 	"notes-api/utils"
 )
 
@@ -20,7 +22,7 @@ func NewNotesHandler() *NotesHandler {
 	return &NotesHandler{}
 }
 
-// CreateNote creates a new note for the authenticated user
+// CreateNote creates a new note for the authenticated user in the personal or addressed workspace
 func (h *NotesHandler) CreateNote(c *fiber.Ctx) error {
 	var req models.NoteCreateRequest
 
@@ -41,27 +43,56 @@ func (h *NotesHandler) CreateNote(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user ID from context
-	userID, err := middleware.GetUserIDFromContext(c)
+	tagNames, err := services.NormalizeTagNames(req.Tags)
+	if err != nil {
+		return tagValidationResponse(c, "tags", err)
+	}
+
+	// Resolve the workspace the notes belong to
+	scope, err := noteScope(c)
 	if err != nil {
-		return err
+		return noteScopeErrorResponse(c, err)
+	}
+
+	// The notebook must belong to the same workspace
+	if req.NotebookID != nil {
+		if _, err := scope.FindNotebook(*req.NotebookID); err != nil {
+			return notebookErrorResponse(c, err)
+		}
 	}
 
 	// Create new note
 	note := models.Note{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  userID,
+		Title:       req.Title,
+		Content:     req.Content,
+		UserID:      scope.UserID,
+		WorkspaceID: scope.WorkspaceID(),
+		NotebookID:  req.NotebookID,
 	}
 
-	// Save note to database
-	if err := config.GetDB().Create(&note).Error; err != nil {
+	// Save note and its tags to database
+	if err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if len(tagNames) == 0 {
+			return nil
+		}
+		return scope.SetNoteTags(tx, &note, tagNames)
+	}); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to create note",
 		})
 	}
 
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNoteCreated,
+		TargetType: models.AuditTargetNote,
+		TargetID:   auditTargetID(note.ID),
+		Details:    scope.AuditDetails(),
+	})
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"error":   false,
 		"message": "Note created successfully",
@@ -69,12 +100,12 @@ func (h *NotesHandler) CreateNote(c *fiber.Ctx) error {
 	})
 }
 
-// GetNotes retrieves all notes for the authenticated user with pagination and search
+// GetNotes retrieves all notes of the workspace with pagination and search
 func (h *NotesHandler) GetNotes(c *fiber.Ctx) error {
-	// Get user ID from context
-	userID, err := middleware.GetUserIDFromContext(c)
+	// Resolve the workspace the notes belong to
+	scope, err := noteScope(c)
 	if err != nil {
-		return err
+		return noteScopeErrorResponse(c, err)
 	}
 
 	// Parse pagination parameters
@@ -91,17 +122,58 @@ func (h *NotesHandler) GetNotes(c *fiber.Ctx) error {
 	// Parse search parameter
 	search := strings.TrimSpace(c.Query("search", ""))
 
+	// Parse tag filter (?tags=a,b&tag_match=any|all)
+	var tagNames []string
+	if tags := strings.TrimSpace(c.Query("tags")); tags != "" {
+		if tagNames, err = services.NormalizeTagNames(strings.Split(tags, ",")); err != nil {
+			return tagValidationResponse(c, "tags", err)
+		}
+	}
+	tagMatch := c.Query("tag_match", "any")
+	if tagMatch != "any" && tagMatch != "all" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "tag_match must be any or all",
+		})
+	}
+
+	// Parse notebook filter (?notebook_id=N, with recursive=true to include nested notebooks)
+	var notebookID uint64
+	if param := c.Query("notebook_id"); param != "" {
+		if notebookID, err = strconv.ParseUint(param, 10, 32); err != nil {
+			return notebookErrorResponse(c, services.ErrNotebookNotFound)
+		}
+		if _, err := scope.FindNotebook(uint(notebookID)); err != nil {
+			return notebookErrorResponse(c, err)
+		}
+	}
+
 	// Build query
-	query := config.GetDB().Where("user_id = ?", userID)
+	query := scope.Notes()
 
 	// Add search filter if provided
 	if search != "" {
-		query = query.Where("title LIKE ? OR content LIKE ?", "%"+search+"%", "%"+search+"%")
+		query = query.Where("notes.title LIKE ? OR notes.content LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	// Add tag filter if provided
+	if len(tagNames) > 0 {
+		query = scope.FilterByTags(query, tagNames, tagMatch == "all")
+	}
+
+	// Add notebook filter if provided
+	if notebookID != 0 {
+		if query, err = scope.FilterByNotebook(query, uint(notebookID), c.QueryBool("recursive")); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to fetch notes",
+			})
+		}
 	}
 
 	// Count total records
 	var total int64
-	if err := query.Model(&models.Note{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to count notes",
@@ -114,7 +186,7 @@ func (h *NotesHandler) GetNotes(c *fiber.Ctx) error {
 
 	// Fetch notes with pagination
 	var notes []models.Note
-	if err := query.Offset(offset).Limit(perPage).Order("created_at DESC").Find(&notes).Error; err != nil {
+	if err := scope.PreloadTags(query).Offset(offset).Limit(perPage).Order("notes.created_at DESC").Find(&notes).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to fetch notes",
@@ -145,7 +217,7 @@ func (h *NotesHandler) GetNotes(c *fiber.Ctx) error {
 	})
 }
 
-// GetNote retrieves a specific note by ID (only if it belongs to the authenticated user)
+// GetNote retrieves a specific note by ID (only if it is in a workspace the user can access)
 func (h *NotesHandler) GetNote(c *fiber.Ctx) error {
 	// Get note ID from URL parameter
 	noteID, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -156,15 +228,15 @@ func (h *NotesHandler) GetNote(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user ID from context
-	userID, err := middleware.GetUserIDFromContext(c)
+	// Resolve the workspace the notes belong to
+	scope, err := noteScope(c)
 	if err != nil {
-		return err
+		return noteScopeErrorResponse(c, err)
 	}
 
 	// Find note
 	var note models.Note
-	if err := config.GetDB().Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+	if err := scope.PreloadTags(scope.Notes()).Where("notes.id = ?", noteID).First(&note).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "Note not found",
@@ -178,7 +250,7 @@ func (h *NotesHandler) GetNote(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateNote updates a specific note (only if it belongs to the authenticated user)
+// UpdateNote updates a specific note (own notes, or any workspace note for owners and admins)
 func (h *NotesHandler) UpdateNote(c *fiber.Ctx) error {
 	// Get note ID from URL parameter
 	noteID, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -208,32 +280,60 @@ func (h *NotesHandler) UpdateNote(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user ID from context
-	userID, err := middleware.GetUserIDFromContext(c)
+	tagNames, err := services.NormalizeTagNames(req.Tags)
+	if err != nil {
+		return tagValidationResponse(c, "tags", err)
+	}
+
+	// Resolve the workspace the notes belong to
+	scope, err := noteScope(c)
 	if err != nil {
-		return err
+		return noteScopeErrorResponse(c, err)
 	}
 
 	// Find note
 	var note models.Note
-	if err := config.GetDB().Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+	if err := scope.PreloadTags(scope.Notes()).Where("notes.id = ?", noteID).First(&note).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "Note not found",
 		})
 	}
 
-	// Update note
+	// Members may only edit other people's notes with an owner or admin role
+	if !scope.CanModify(&note) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "You can only change your own notes in this workspace",
+		})
+	}
+
+	// Update note; tags are only replaced when the request lists them
 	note.Title = req.Title
 	note.Content = req.Content
 
-	if err := config.GetDB().Save(&note).Error; err != nil {
+	if err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").Save(&note).Error; err != nil {
+			return err
+		}
+		if tagNames == nil {
+			return nil
+		}
+		return scope.SetNoteTags(tx, &note, tagNames)
+	}); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to update note",
 		})
 	}
 
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNoteUpdated,
+		TargetType: models.AuditTargetNote,
+		TargetID:   auditTargetID(note.ID),
+		Details:    scope.AuditDetails(),
+	})
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Note updated successfully",
@@ -241,8 +341,8 @@ func (h *NotesHandler) UpdateNote(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteNote deletes a specific note (only if it belongs to the authenticated user)
-func (h *NotesHandler) DeleteNote(c *fiber.Ctx) error {
+// MoveNote files a note in another notebook of its workspace, or takes it out of all notebooks
+func (h *NotesHandler) MoveNote(c *fiber.Ctx) error {
 	// Get note ID from URL parameter
 	noteID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
@@ -252,30 +352,140 @@ func (h *NotesHandler) DeleteNote(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user ID from context
-	userID, err := middleware.GetUserIDFromContext(c)
+	var req models.NoteMoveRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Resolve the workspace the notes belong to
+	scope, err := noteScope(c)
 	if err != nil {
-		return err
+		return noteScopeErrorResponse(c, err)
 	}
 
-	// Find and delete note
-	result := config.GetDB().Where("id = ? AND user_id = ?", noteID, userID).Delete(&models.Note{})
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	// Find note
+	var note models.Note
+	if err := scope.PreloadTags(scope.Notes()).Where("notes.id = ?", noteID).First(&note).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
-			"message": "Failed to delete note",
+			"message": "Note not found",
+		})
+	}
+
+	// Members may only move other people's notes with an owner or admin role
+	if !scope.CanModify(&note) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "You can only change your own notes in this workspace",
+		})
+	}
+
+	// Move note
+	if err := services.MoveNote(scope, &note, req.NotebookID); err != nil {
+		return notebookErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNoteMoved,
+		TargetType: models.AuditTargetNote,
+		TargetID:   auditTargetID(note.ID),
+		Details:    joinAuditDetails(scope.AuditDetails(), notebookDestination("to", note.NotebookID)),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Note moved successfully",
+		"data":    note.ToResponse(),
+	})
+}
+
+// DeleteNote deletes a specific note (own notes, or any workspace note for owners and admins)
+func (h *NotesHandler) DeleteNote(c *fiber.Ctx) error {
+	// Get note ID from URL parameter
+	noteID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid note ID",
 		})
 	}
 
-	if result.RowsAffected == 0 {
+	// Resolve the workspace the notes belong to
+	scope, err := noteScope(c)
+	if err != nil {
+		return noteScopeErrorResponse(c, err)
+	}
+
+	// Find note
+	var note models.Note
+	if err := scope.Notes().Where("notes.id = ?", noteID).First(&note).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "Note not found",
 		})
 	}
 
+	// Members may only delete other people's notes with an owner or admin role
+	if !scope.CanModify(&note) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "You can only change your own notes in this workspace",
+		})
+	}
+
+	// Delete note
+	if err := config.GetDB().Delete(&note).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete note",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditNoteDeleted,
+		TargetType: models.AuditTargetNote,
+		TargetID:   auditTargetID(note.ID),
+		Details:    scope.AuditDetails(),
+	})
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Note deleted successfully",
 	})
 }
+
+// noteScope resolves the workspace addressed by the route; plain /notes routes use the personal workspace
+func noteScope(c *fiber.Ctx) (*services.NoteScope, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var workspaceID *uint
+	if param := c.Params("workspaceId"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return nil, services.ErrWorkspaceNotFound
+		}
+		value := uint(id)
+		workspaceID = &value
+	}
+
+	return services.ResolveNoteScope(userID, workspaceID)
+}
+
+// noteScopeErrorResponse rejects requests for workspaces the user does not belong to
+func noteScopeErrorResponse(c *fiber.Ctx, err error) error {
+	if err == services.ErrWorkspaceNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Workspace not found",
+		})
+	}
+	return err
+}
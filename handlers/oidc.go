@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/services"
+)
+
+const (
+	// oidcStateCookie binds an OIDC login to the browser that started it
+	oidcStateCookie = "oidc_state"
+
+	// oidcAuthModeCookie remembers across the redirect that the login should end in a cookie session
+	oidcAuthModeCookie = "oidc_auth_mode"
+)
+
+// OIDCProviders lists the configured OpenID Connect providers
+func (h *AuthHandler) OIDCProviders(c *fiber.Ctx) error {
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Providers retrieved successfully",
+		"data":    names,
+	})
+}
+
+// OIDCLogin redirects the user to the identity provider using the authorization code flow with PKCE
+func (h *AuthHandler) OIDCLogin(c *fiber.Ctx) error {
+	// Find provider
+	provider, ok := h.providers[c.Params("provider")]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unknown identity provider",
+		})
+	}
+
+	// Store state, nonce and PKCE verifier for the callback
+	state, nonce, verifier, err := services.CreateOIDCLoginState(provider.Name())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start login",
+		})
+	}
+
+	// Build authorization URL (runs discovery on first use)
+	authURL, err := provider.AuthCodeURL(c.UserContext(), state, nonce, verifier)
+	if err != nil {
+		log.Println("OIDC login failed:", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   true,
+			"message": "Identity provider is unavailable",
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/v1/auth/oidc",
+		Expires:  time.Now().Add(10 * time.Minute),
+		HTTPOnly: true,
+		Secure:   secureCookies(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	// Browser clients start the login with ?mode=cookie to receive a cookie session
+	modeExpires := time.Now().Add(10 * time.Minute)
+	if c.Query("mode") != authModeCookie {
+		modeExpires = time.Unix(0, 0)
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcAuthModeCookie,
+		Value:    c.Query("mode"),
+		Path:     "/api/v1/auth/oidc",
+		Expires:  modeExpires,
+		HTTPOnly: true,
+		Secure:   secureCookies(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OIDCCallback completes the login after the identity provider redirects back
+func (h *AuthHandler) OIDCCallback(c *fiber.Ctx) error {
+	// Find provider
+	provider, ok := h.providers[c.Params("provider")]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unknown identity provider",
+		})
+	}
+
+	// Surface errors reported by the provider
+	if errCode := c.Query("error"); errCode != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Identity provider returned an error: " + errCode,
+		})
+	}
+
+	// The state must match the one stored in this browser
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" || c.Cookies(oidcStateCookie) != state {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired login state",
+		})
+	}
+	c.ClearCookie(oidcStateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcAuthModeCookie,
+		Path:     "/api/v1/auth/oidc",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		Secure:   secureCookies(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	loginState, err := services.ConsumeOIDCLoginState(provider.Name(), state)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired login state",
+		})
+	}
+
+	// Exchange code and validate ID token
+	tokens, err := provider.Exchange(c.UserContext(), code, loginState.CodeVerifier)
+	if err != nil {
+		log.Println("OIDC code exchange failed:", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete login with identity provider",
+		})
+	}
+
+	claims, err := provider.VerifyIDToken(c.UserContext(), tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		log.Println("OIDC ID token rejected:", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID token",
+		})
+	}
+
+	// Link or create the local user
+	user, err := services.FindOrCreateOIDCUser(provider.Name(), claims)
+	if err != nil {
+		if err == services.ErrOIDCEmailNotVerified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Your identity provider did not verify your email address",
+			})
+		}
+		if err == services.ErrOIDCAccountDeleted {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "This account has been deleted",
+			})
+		}
+		if err == services.ErrOIDCAccountConflict {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "An unverified account with this email address already exists; sign in with its password and verify the address first",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to sign in user",
+		})
+	}
+
+	return h.completeLogin(c, user)
+}
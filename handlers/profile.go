@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/mailer"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// UpdateProfile changes the authenticated user's name
+func (h *AuthHandler) UpdateProfile(c *fiber.Ctx) error {
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ProfileUpdateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Update profile
+	if err := services.UpdateProfile(user, req.Name); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update profile",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditProfileUpdated})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Profile updated successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// ChangePassword sets a new password after checking the current one and signs out other sessions.
+// Accounts created from an external identity use it to set their first password.
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	// Get user and session from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+	session, err := middleware.GetSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ChangePasswordRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Reject passwords resembling the account's email address
+	if message := utils.CheckPasswordPolicy(req.NewPassword, user.Email); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  utils.ValidationErrors{{Field: "new_password", Message: message}},
+		})
+	}
+
+	// Check current password, or a second factor or recent login for accounts without one
+	if message := reauthenticationError(user, session, req.CurrentPassword, req.Code); message != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	// Update password and sign out other sessions
+	tokens, err := services.ChangePassword(user, session, req.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to change password",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditPasswordChanged})
+
+	return respondWithTokens(c, fiber.StatusOK, "Password changed successfully", user, tokens)
+}
+
+// RequestEmailChange sends a confirmation link to the new address
+func (h *AuthHandler) RequestEmailChange(c *fiber.Ctx) error {
+	// Get user and session from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+	session, err := middleware.GetSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ChangeEmailRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Check current password, or a second factor or recent login for accounts without one
+	if message := reauthenticationError(user, session, req.CurrentPassword, req.Code); message != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	// Check the new address
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "New email address must differ from the current one",
+		})
+	}
+	if err := services.EnsureEmailAvailable(newEmail); err != nil {
+		if err == services.ErrEmailTaken {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "User with this email already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check email address",
+		})
+	}
+
+	// Issue confirmation token bound to the new address
+	ttl := emailChangeTTL()
+	token, err := services.CreateOneTimeToken(user.ID, models.TokenPurposeEmailChange, ttl, newEmail)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create email change token",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditEmailChangeRequested, Email: newEmail})
+
+	// Send confirmation email to the new address
+	link := frontendURL("/confirm-email-change", token)
+	if err := h.mailer.Send(mailer.EmailChangeMessage(newEmail, user.Name, link, ttl)); err != nil {
+		log.Println("Failed to send email change confirmation:", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"error":   false,
+		"message": "A confirmation link has been sent to the new email address",
+	})
+}
+
+// ConfirmEmailChange swaps the email address once the new one is confirmed and signs out other sessions
+func (h *AuthHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	// Get user and session from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+	session, err := middleware.GetSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ConfirmEmailChangeRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Redeem token and change email
+	tokens, oldEmail, err := services.ConfirmEmailChange(user, session, req.Token)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidOneTimeToken:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid or expired email change token",
+			})
+		case services.ErrEmailTaken:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "User with this email already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to change email",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:  models.AuditEmailChanged,
+		Email:   user.Email,
+		Details: "changed from " + oldEmail,
+	})
+
+	// Let the previous address know about the change
+	if err := h.mailer.Send(mailer.EmailChangedMessage(oldEmail, user.Name, user.Email)); err != nil {
+		log.Println("Failed to send email change notice:", err)
+	}
+
+	return respondWithTokens(c, fiber.StatusOK, "Email changed successfully", user, tokens)
+}
+
+// DeleteAccount schedules the account for deletion after the grace period and signs out everywhere
+func (h *AuthHandler) DeleteAccount(c *fiber.Ctx) error {
+	// Get user and session from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+	session, err := middleware.GetSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.DeleteAccountRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Check current password, or a second factor or recent login for accounts without one
+	if message := reauthenticationError(user, session, req.CurrentPassword, req.Code); message != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	// Schedule deletion
+	if err := services.ScheduleAccountDeletion(user); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to schedule account deletion",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:  models.AuditAccountDeletion,
+		Details: "due at " + user.DeletionDueAt.UTC().Format(time.RFC3339),
+	})
+
+	// Confirm by email
+	if err := h.mailer.Send(mailer.AccountDeletionMessage(user.Email, user.Name, *user.DeletionDueAt)); err != nil {
+		log.Println("Failed to send account deletion email:", err)
+	}
+
+	if middleware.IsCookieSession(c) {
+		clearSessionCookies(c)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"error":   false,
+		"message": "Account scheduled for deletion; sign in again before the deletion date to cancel",
+		"data": fiber.Map{
+			"deletion_due_at": user.DeletionDueAt,
+		},
+	})
+}
+
+// ExportAccount downloads a zip archive with the user's profile data and notes
+func (h *AuthHandler) ExportAccount(c *fiber.Ctx) error {
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Build archive
+	archive, err := services.BuildAccountExport(user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to export account",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditAccountExported})
+
+	filename := "notes-export-" + time.Now().UTC().Format("20060102") + ".zip"
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(archive)
+}
+
+// reauthenticationError confirms a sensitive account change and returns why it was refused, or "".
+// Accounts with a local password confirm with it. Accounts created from an external identity have
+// no password the user knows, so a second factor or a login within the reauthentication window counts.
+func reauthenticationError(user *models.User, session *models.Session, password, code string) string {
+	if user.HasLocalPassword() {
+		if !user.CheckPassword(password) {
+			return "Current password is incorrect"
+		}
+		return ""
+	}
+
+	if code != "" && user.IsTwoFactorEnabled() {
+		if services.VerifySecondFactor(user, code) != nil {
+			return "Invalid two-factor code"
+		}
+		return ""
+	}
+	if time.Since(session.CreatedAt) > reauthenticationWindow() {
+		return "Sign in again or confirm with a two-factor code to make this change"
+	}
+	return ""
+}
+
+// reauthenticationWindow returns how long after a login accounts without a password may make
+// sensitive changes without a second factor (default: 10 minutes)
+func reauthenticationWindow() time.Duration {
+	return utils.GetEnvDuration("REAUTHENTICATION_WINDOW_MINUTES", time.Minute, 10*time.Minute)
+}
+
+// emailChangeTTL returns how long email change links stay valid (default: 1 hour)
+func emailChangeTTL() time.Duration {
+	return utils.GetEnvDuration("EMAIL_CHANGE_EXPIRATION_MINUTES", time.Minute, time.Hour)
+}
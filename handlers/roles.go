@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// RolesHandler handles role management operations
+type RolesHandler struct{}
+
+// NewRolesHandler creates a new roles handler
+func NewRolesHandler() *RolesHandler {
+	return &RolesHandler{}
+}
+
+// GetRoles lists all roles and their permissions
+func (h *RolesHandler) GetRoles(c *fiber.Ctx) error {
+	// Fetch roles
+	roles, err := services.ListRoles()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch roles",
+		})
+	}
+
+	// Convert to response format
+	roleResponses := make([]models.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		roleResponses = append(roleResponses, role.ToResponse())
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Roles retrieved successfully",
+		"data":    roleResponses,
+	})
+}
+
+// AssignRole grants a role to a user; their sessions are signed out
+func (h *RolesHandler) AssignRole(c *fiber.Ctx) error {
+	// Get user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	var req models.RoleAssignRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Assign role
+	user, err := services.AssignRole(uint(userID), req.Role)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditRoleAssigned, user.ID, req.Role)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Role assigned successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// RemoveRole takes a role away from a user; their sessions are signed out
+func (h *RolesHandler) RemoveRole(c *fiber.Ctx) error {
+	// Get user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Remove role
+	user, err := services.RemoveRole(uint(userID), c.Params("role"))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	recordAdminAudit(c, models.AuditRoleRemoved, user.ID, c.Params("role"))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Role removed successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// roleErrorResponse maps role assignment errors to responses
+func roleErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	case services.ErrRoleNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Role not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": "Failed to update roles",
+	})
+}
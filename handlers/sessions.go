@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+)
+
+// SessionsHandler handles session management operations
+type SessionsHandler struct{}
+
+// NewSessionsHandler creates a new sessions handler
+func NewSessionsHandler() *SessionsHandler {
+	return &SessionsHandler{}
+}
+
+// GetSessions lists the authenticated user's active sessions
+func (h *SessionsHandler) GetSessions(c *fiber.Ctx) error {
+	// Get current session from context
+	current, err := middleware.GetSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Fetch active sessions
+	sessions, err := services.ListActiveSessions(current.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch sessions",
+		})
+	}
+
+	// Convert to response format
+	sessionResponses := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		sessionResponses = append(sessionResponses, session.ToResponse(current.ID))
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Sessions retrieved successfully",
+		"data":    sessionResponses,
+	})
+}
+
+// RevokeSession signs out a specific session of the authenticated user
+func (h *SessionsHandler) RevokeSession(c *fiber.Ctx) error {
+	// Get session ID from URL parameter
+	sessionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid session ID",
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Revoke session
+	if err := services.RevokeSession(userID, uint(sessionID)); err != nil {
+		if err == services.ErrSessionNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "Session not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke session",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditSessionRevoked,
+		TargetType: models.AuditTargetSession,
+		TargetID:   auditTargetID(uint(sessionID)),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeOtherSessions signs out every session except the current one
+func (h *SessionsHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	// Get current session from context
+	current, err := middleware.GetSessionFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Revoke all other sessions
+	revoked, err := services.RevokeOtherSessions(current.UserID, current.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke sessions",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:  models.AuditOtherSessionsRevoked,
+		Details: fmt.Sprintf("%d sessions revoked", revoked),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Other sessions revoked successfully",
+		"data": fiber.Map{
+			"revoked": revoked,
+		},
+	})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// TagsHandler handles management of the user's note tags
+type TagsHandler struct{}
+
+// NewTagsHandler creates a new tags handler
+func NewTagsHandler() *TagsHandler {
+	return &TagsHandler{}
+}
+
+// GetTags lists the authenticated user's tags with their note counts
+func (h *TagsHandler) GetTags(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Fetch tags
+	tags, err := services.ListTags(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tags",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Tags retrieved successfully",
+		"data":    tags,
+	})
+}
+
+// CreateTag creates a tag without attaching it to any note
+func (h *TagsHandler) CreateTag(c *fiber.Ctx) error {
+	var req models.TagCreateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Create tag
+	tag, err := services.CreateTag(userID, req.Name)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Tag created successfully",
+		"data":    tag,
+	})
+}
+
+// RenameTag renames one of the user's tags on every note carrying it
+func (h *TagsHandler) RenameTag(c *fiber.Ctx) error {
+	// Get tag ID from URL parameter
+	tagID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid tag ID",
+		})
+	}
+
+	var req models.TagRenameRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Rename tag
+	tag, err := services.RenameTag(userID, uint(tagID), req.Name)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Tag renamed successfully",
+		"data":    tag,
+	})
+}
+
+// MergeTag moves the notes of a tag to another tag and deletes it
+func (h *TagsHandler) MergeTag(c *fiber.Ctx) error {
+	// Get tag ID from URL parameter
+	tagID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid tag ID",
+		})
+	}
+
+	var req models.TagMergeRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Merge tags
+	tag, err := services.MergeTags(userID, uint(tagID), req.IntoTagID)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Tags merged successfully",
+		"data":    tag,
+	})
+}
+
+// DeleteTag removes a tag from all notes and deletes it; the notes themselves are kept
+func (h *TagsHandler) DeleteTag(c *fiber.Ctx) error {
+	// Get tag ID from URL parameter
+	tagID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid tag ID",
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Delete tag
+	if err := services.DeleteTag(userID, uint(tagID)); err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Tag deleted successfully",
+	})
+}
+
+// tagErrorResponse maps tag errors to responses
+func tagErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrTagNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tag not found",
+		})
+	case services.ErrTagExists:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "A tag with this name already exists; merge the tags instead",
+		})
+	case services.ErrInvalidTagName:
+		return tagValidationResponse(c, "name", err)
+	case services.ErrMergeTagIntoItself:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "A tag cannot be merged into itself",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": "Failed to update tag",
+	})
+}
+
+// tagValidationResponse rejects invalid tag names in the validation error format
+func tagValidationResponse(c *fiber.Ctx, field string, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":   true,
+		"message": "Validation failed",
+		"errors": utils.ValidationErrors{{
+			Field:   field,
+			Message: err.Error(),
+		}},
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// TokensHandler handles personal access token operations
+type TokensHandler struct{}
+
+// NewTokensHandler creates a new personal access tokens handler
+func NewTokensHandler() *TokensHandler {
+	return &TokensHandler{}
+}
+
+// CreateToken creates a new personal access token for the authenticated user
+func (h *TokensHandler) CreateToken(c *fiber.Ctx) error {
+	var req models.PersonalAccessTokenCreateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	errors := utils.ValidateStruct(req)
+	for _, scope := range req.Scopes {
+		if !models.IsValidScope(scope) {
+			errors = append(errors, utils.ValidationError{
+				Field:   "scopes",
+				Message: "unknown scope " + strconv.Quote(scope),
+			})
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		errors = append(errors, utils.ValidationError{
+			Field:   "expires_in_days",
+			Message: "must not be negative",
+		})
+	}
+	if len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Tokens without an expiry stay valid until revoked
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		expiry := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &expiry
+	}
+
+	// Create token
+	rawToken, token, err := services.CreatePersonalAccessToken(userID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create token",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditTokenCreated,
+		TargetType: models.AuditTargetToken,
+		TargetID:   auditTargetID(token.ID),
+		Details:    token.Name,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Token created successfully. Copy it now, it will not be shown again",
+		"data": fiber.Map{
+			"token":   rawToken,
+			"details": token.ToResponse(),
+		},
+	})
+}
+
+// GetTokens lists the authenticated user's personal access tokens
+func (h *TokensHandler) GetTokens(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Fetch tokens
+	tokens, err := services.ListPersonalAccessTokens(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tokens",
+		})
+	}
+
+	// Convert to response format
+	tokenResponses := make([]models.PersonalAccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		tokenResponses = append(tokenResponses, token.ToResponse())
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Tokens retrieved successfully",
+		"data":    tokenResponses,
+	})
+}
+
+// RevokeToken revokes one of the authenticated user's personal access tokens
+func (h *TokensHandler) RevokeToken(c *fiber.Ctx) error {
+	// Get token ID from URL parameter
+	tokenID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid token ID",
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Revoke token
+	if err := services.RevokePersonalAccessToken(userID, uint(tokenID)); err != nil {
+		if err == services.ErrPersonalAccessTokenNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "Token not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke token",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditTokenRevoked,
+		TargetType: models.AuditTargetToken,
+		TargetID:   auditTargetID(uint(tokenID)),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Token revoked successfully",
+	})
+}
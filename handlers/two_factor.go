@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/config"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// EnrollTwoFactor starts TOTP enrollment and returns the secret and otpauth URI
+func (h *AuthHandler) EnrollTwoFactor(c *fiber.Ctx) error {
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Generate pending secret
+	secret, uri, err := services.BeginTOTPEnrollment(user)
+	if err != nil {
+		if err == services.ErrTwoFactorAlreadyEnabled {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Two-factor authentication is already enabled",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start two-factor enrollment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Scan the QR code with your authenticator app and confirm with a code",
+		"data": fiber.Map{
+			"secret":      secret,
+			"otpauth_uri": uri,
+		},
+	})
+}
+
+// ConfirmTwoFactor enables TOTP after verifying a code and returns recovery codes
+func (h *AuthHandler) ConfirmTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorConfirmRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Enable two-factor authentication
+	codes, err := services.ConfirmTOTPEnrollment(user, req.Code)
+	if err != nil {
+		switch err {
+		case services.ErrTwoFactorAlreadyEnabled:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Two-factor authentication is already enabled",
+			})
+		case services.ErrTwoFactorNotEnrolled:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Two-factor enrollment has not been started",
+			})
+		case services.ErrInvalidTwoFactorCode:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid two-factor code",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to enable two-factor authentication",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditTwoFactorEnabled})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Two-factor authentication enabled. Store the recovery codes somewhere safe, they are only shown once",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableTwoFactor turns off TOTP after re-checking the password and a current code
+func (h *AuthHandler) DisableTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorDisableRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication is not enabled",
+		})
+	}
+
+	// Check password and second factor; accounts created from an external identity
+	// have no password the user knows, so the second factor alone confirms them
+	passwordOK := !user.HasLocalPassword() || user.CheckPassword(req.Password)
+	if !passwordOK || services.VerifySecondFactor(user, req.Code) != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid password or two-factor code",
+		})
+	}
+
+	// Disable two-factor authentication
+	if err := services.DisableTOTP(user); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to disable two-factor authentication",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{Action: models.AuditTwoFactorDisabled})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// VerifyTwoFactor exchanges an "mfa pending" token and a TOTP or recovery code for real tokens
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorVerifyRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Validate mfa pending token
+	claims, err := utils.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired MFA token",
+		})
+	}
+
+	// Find user
+	var user models.User
+	if err := config.GetDB().First(&user, claims.UserID).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired MFA token",
+		})
+	}
+
+	// Reject accounts disabled since the first factor was checked
+	if user.IsDisabled() {
+		return accountDisabledResponse(c)
+	}
+
+	// Second factor guesses count against the same limits as passwords
+	throttleKeys := services.LoginKeys(user.Email, c.IP())
+	if retryAfter, err := h.throttle.RetryAfter(throttleKeys...); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check login attempts",
+		})
+	} else if retryAfter > 0 {
+		return tooManyLoginAttemptsResponse(c, retryAfter)
+	}
+
+	// Check second factor
+	if err := services.VerifySecondFactor(&user, req.Code); err != nil {
+		if _, err := h.throttle.RecordFailure(throttleKeys...); err != nil {
+			log.Println("Failed to record login attempt:", err)
+		}
+		recordAudit(c, models.AuditEvent{
+			Action:  models.AuditLoginFailed,
+			UserID:  &user.ID,
+			Email:   user.Email,
+			Details: "invalid two-factor code",
+		})
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid two-factor code",
+		})
+	}
+
+	return h.issueLoginTokens(c, &user)
+}
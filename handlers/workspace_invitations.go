@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/mailer"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// CreateInvitation invites an email address to a workspace and emails the invitation link
+func (h *WorkspacesHandler) CreateInvitation(c *fiber.Ctx) error {
+	var req models.WorkspaceInvitationCreateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Create invitation
+	invitation, token, err := services.CreateWorkspaceInvitation(membership, req.Email, req.Role)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordInvitationAudit(c, models.AuditInvitationCreated, invitation)
+	h.sendInvitationEmail(c, invitation, token)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Invitation sent successfully",
+		"data":    invitation.ToResponse(),
+	})
+}
+
+// GetInvitations lists the pending invitations of a workspace (owners and admins only)
+func (h *WorkspacesHandler) GetInvitations(c *fiber.Ctx) error {
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+	if !membership.CanManageMembers() {
+		return workspaceErrorResponse(c, services.ErrWorkspaceForbidden)
+	}
+
+	// Fetch invitations
+	invitations, err := services.ListPendingWorkspaceInvitations(membership.WorkspaceID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch invitations",
+		})
+	}
+
+	// Convert to response format
+	invitationResponses := make([]models.WorkspaceInvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitationResponses = append(invitationResponses, invitation.ToResponse())
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Invitations retrieved successfully",
+		"data":    invitationResponses,
+	})
+}
+
+// RevokeInvitation cancels a pending invitation (owners and admins only)
+func (h *WorkspacesHandler) RevokeInvitation(c *fiber.Ctx) error {
+	// Get invitation ID from URL parameter
+	invitationID, err := strconv.ParseUint(c.Params("invitationId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid invitation ID",
+		})
+	}
+
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Revoke invitation
+	if err := services.RevokeWorkspaceInvitation(membership, uint(invitationID)); err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordInvitationAudit(c, models.AuditInvitationRevoked, &models.WorkspaceInvitation{
+		ID:          uint(invitationID),
+		WorkspaceID: membership.WorkspaceID,
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Invitation revoked successfully",
+	})
+}
+
+// ResendInvitation emails a fresh link for a pending or expired invitation (owners and admins only)
+func (h *WorkspacesHandler) ResendInvitation(c *fiber.Ctx) error {
+	// Get invitation ID from URL parameter
+	invitationID, err := strconv.ParseUint(c.Params("invitationId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid invitation ID",
+		})
+	}
+
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Replace the link token
+	invitation, token, err := services.ResendWorkspaceInvitation(membership, uint(invitationID))
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordInvitationAudit(c, models.AuditInvitationResent, invitation)
+	h.sendInvitationEmail(c, invitation, token)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Invitation resent successfully",
+		"data":    invitation.ToResponse(),
+	})
+}
+
+// PreviewInvitation describes an invitation to the holder of its link so they can sign in or register
+func (h *WorkspacesHandler) PreviewInvitation(c *fiber.Ctx) error {
+	var req models.InvitationTokenRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Look up invitation
+	preview, err := services.PreviewWorkspaceInvitation(req.Token)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Invitation retrieved successfully",
+		"data":    preview,
+	})
+}
+
+// AcceptInvitation adds the authenticated user to the workspace they were invited to
+func (h *WorkspacesHandler) AcceptInvitation(c *fiber.Ctx) error {
+	var req models.InvitationTokenRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user from context
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Accept invitation
+	member, invitation, err := services.AcceptWorkspaceInvitation(user, req.Token)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordInvitationAudit(c, models.AuditInvitationAccepted, invitation)
+
+	// Respond with the joined workspace
+	workspace, err := services.GetWorkspace(member)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Invitation accepted successfully",
+		"data":    workspace,
+	})
+}
+
+// sendInvitationEmail mails the invitation link; delivery failures are logged
+func (h *WorkspacesHandler) sendInvitationEmail(c *fiber.Ctx, invitation *models.WorkspaceInvitation, token string) {
+	inviterName := "A teammate"
+	if user, err := middleware.GetUserFromContext(c); err == nil {
+		inviterName = user.Name
+	}
+
+	link := frontendURL("/accept-invitation", token)
+	message := mailer.WorkspaceInvitationMessage(invitation.Email, inviterName, invitation.Workspace.Name, invitation.Role, link, services.WorkspaceInvitationTTL())
+	if err := h.mailer.Send(message); err != nil {
+		log.Println("Failed to send workspace invitation:", err)
+	}
+}
+
+// recordInvitationAudit appends an event for a change to a workspace invitation
+func recordInvitationAudit(c *fiber.Ctx, action string, invitation *models.WorkspaceInvitation) {
+	details := "invitation " + auditTargetID(invitation.ID)
+	if invitation.Email != "" {
+		details += " for " + invitation.Email + " as " + invitation.Role
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     action,
+		Email:      invitation.Email,
+		TargetType: models.AuditTargetWorkspace,
+		TargetID:   auditTargetID(invitation.WorkspaceID),
+		Details:    details,
+	})
+}
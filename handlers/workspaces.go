@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"notes-api/mailer"
+	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/services"
+	"notes-api/utils"
+)
+
+// WorkspacesHandler handles workspace, membership and invitation management
+type WorkspacesHandler struct {
+	mailer mailer.Mailer
+}
+
+// NewWorkspacesHandler creates a new workspaces handler
+func NewWorkspacesHandler(mail mailer.Mailer) *WorkspacesHandler {
+	return &WorkspacesHandler{mailer: mail}
+}
+
+// CreateWorkspace creates a workspace owned by the authenticated user
+func (h *WorkspacesHandler) CreateWorkspace(c *fiber.Ctx) error {
+	var req models.WorkspaceCreateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Create workspace
+	workspace, err := services.CreateWorkspace(userID, req.Name)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create workspace",
+		})
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditWorkspaceCreated,
+		TargetType: models.AuditTargetWorkspace,
+		TargetID:   auditTargetID(workspace.ID),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Workspace created successfully",
+		"data":    workspace.ToResponse(models.WorkspaceRoleOwner, 1),
+	})
+}
+
+// GetWorkspaces lists the workspaces the authenticated user belongs to
+func (h *WorkspacesHandler) GetWorkspaces(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Fetch workspaces
+	workspaces, err := services.ListWorkspaces(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch workspaces",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Workspaces retrieved successfully",
+		"data":    workspaces,
+	})
+}
+
+// GetWorkspace retrieves a workspace the authenticated user belongs to
+func (h *WorkspacesHandler) GetWorkspace(c *fiber.Ctx) error {
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Fetch workspace
+	workspace, err := services.GetWorkspace(membership)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Workspace retrieved successfully",
+		"data":    workspace,
+	})
+}
+
+// UpdateWorkspace renames a workspace (owners and admins only)
+func (h *WorkspacesHandler) UpdateWorkspace(c *fiber.Ctx) error {
+	var req models.WorkspaceUpdateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Rename workspace
+	workspace, err := services.RenameWorkspace(membership, req.Name)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditWorkspaceUpdated,
+		TargetType: models.AuditTargetWorkspace,
+		TargetID:   auditTargetID(workspace.ID),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Workspace updated successfully",
+		"data":    workspace,
+	})
+}
+
+// DeleteWorkspace deletes a workspace and its notes (owner only)
+func (h *WorkspacesHandler) DeleteWorkspace(c *fiber.Ctx) error {
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Delete workspace
+	if err := services.DeleteWorkspace(membership); err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     models.AuditWorkspaceDeleted,
+		TargetType: models.AuditTargetWorkspace,
+		TargetID:   auditTargetID(membership.WorkspaceID),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Workspace deleted successfully",
+	})
+}
+
+// GetMembers lists the members of a workspace
+func (h *WorkspacesHandler) GetMembers(c *fiber.Ctx) error {
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Fetch members
+	members, err := services.ListWorkspaceMembers(membership.WorkspaceID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch workspace members",
+		})
+	}
+
+	// Convert to response format
+	memberResponses := make([]models.WorkspaceMemberResponse, 0, len(members))
+	for _, member := range members {
+		memberResponses = append(memberResponses, member.ToResponse())
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Workspace members retrieved successfully",
+		"data":    memberResponses,
+	})
+}
+
+// AddMember invites an email address to a workspace (owners and admins only). Users only join by
+// accepting the emailed invitation, and the response does not reveal whether the address has an account.
+func (h *WorkspacesHandler) AddMember(c *fiber.Ctx) error {
+	return h.CreateInvitation(c)
+}
+
+// UpdateMember changes the role of a workspace member (owner only)
+func (h *WorkspacesHandler) UpdateMember(c *fiber.Ctx) error {
+	// Get member user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	var req models.WorkspaceMemberUpdateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Validation failed",
+			"errors":  errors,
+		})
+	}
+
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Change role
+	member, err := services.UpdateWorkspaceMemberRole(membership, uint(userID), req.Role)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordWorkspaceMemberAudit(c, models.AuditWorkspaceMemberUpdated, member)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Member updated successfully",
+		"data":    member.ToResponse(),
+	})
+}
+
+// RemoveMember removes a member from a workspace; members may remove themselves to leave
+func (h *WorkspacesHandler) RemoveMember(c *fiber.Ctx) error {
+	// Get member user ID from URL parameter
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+	}
+
+	// Get membership of the addressed workspace
+	membership, err := workspaceMembership(c)
+	if err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	// Remove member
+	if err := services.RemoveWorkspaceMember(membership, uint(userID)); err != nil {
+		return workspaceErrorResponse(c, err)
+	}
+
+	recordWorkspaceMemberAudit(c, models.AuditWorkspaceMemberRemoved, &models.WorkspaceMember{
+		WorkspaceID: membership.WorkspaceID,
+		UserID:      uint(userID),
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Member removed successfully",
+	})
+}
+
+// workspaceMembership returns the authenticated user's membership of the workspace in the URL
+func workspaceMembership(c *fiber.Ctx) (*models.WorkspaceMember, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceId"), 10, 32)
+	if err != nil {
+		return nil, services.ErrWorkspaceNotFound
+	}
+
+	return services.GetWorkspaceMembership(uint(workspaceID), userID)
+}
+
+// recordWorkspaceMemberAudit appends an event for a change to a workspace member
+func recordWorkspaceMemberAudit(c *fiber.Ctx, action string, member *models.WorkspaceMember) {
+	details := fmt.Sprintf("user %d", member.UserID)
+	if member.Role != "" {
+		details += " as " + member.Role
+	}
+
+	recordAudit(c, models.AuditEvent{
+		Action:     action,
+		UserID:     &member.UserID,
+		TargetType: models.AuditTargetWorkspace,
+		TargetID:   auditTargetID(member.WorkspaceID),
+		Details:    details,
+	})
+}
+
+// workspaceErrorResponse maps workspace errors to responses
+func workspaceErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrWorkspaceNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Workspace not found",
+		})
+	case services.ErrWorkspaceMemberNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Member not found",
+		})
+	case services.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	case services.ErrWorkspaceForbidden:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Your workspace role does not allow this action",
+		})
+	case services.ErrAlreadyWorkspaceMember:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "User is already a member of this workspace",
+		})
+	case services.ErrInvitationNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invitation not found",
+		})
+	case services.ErrInvalidInvitation:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired invitation",
+		})
+	case services.ErrInvitationEmailMismatch:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "This invitation was sent to a different email address",
+		})
+	case services.ErrWorkspaceOwnerImmutable:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "The workspace owner cannot be removed or demoted",
+		})
+	}
+
+	if fiberErr, ok := err.(*fiber.Error); ok {
+		return fiberErr
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": "Failed to update workspace",
+	})
+}
@@ -0,0 +1,16 @@
+package mailer
+
+import (
+	"log"
+)
+
+// LogMailer writes messages to the application log instead of sending them
+type LogMailer struct {
+	From string
+}
+
+// Send logs the message
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
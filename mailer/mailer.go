@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"notes-api/utils"
+)
+
+// Message represents a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewFromEnv creates the mailer selected by MAIL_DRIVER (smtp, file or log; default: log)
+func NewFromEnv() Mailer {
+	from := utils.GetEnv("MAIL_FROM", "Notes API <no-reply@notes-api.local>")
+
+	switch strings.ToLower(utils.GetEnv("MAIL_DRIVER", "log")) {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     utils.GetEnv("SMTP_HOST", "localhost"),
+			Port:     utils.GetEnvInt("SMTP_PORT", 587),
+			Username: utils.GetEnv("SMTP_USERNAME", ""),
+			Password: utils.GetEnv("SMTP_PASSWORD", ""),
+			From:     from,
+		}
+	case "file":
+		return &FileMailer{
+			Dir:  utils.GetEnv("MAIL_DIR", "./mail"),
+			From: from,
+		}
+	case "log":
+		return &LogMailer{From: from}
+	default:
+		log.Printf("Unknown MAIL_DRIVER %q, falling back to log mailer", utils.GetEnv("MAIL_DRIVER", ""))
+		return &LogMailer{From: from}
+	}
+}
+
+// render builds an RFC 5322 message including headers
+func render(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
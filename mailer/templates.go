@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"fmt"
+	"time"
+)
+
+// PasswordResetMessage builds the email containing a password reset link
+func PasswordResetMessage(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Reset your Notes API password",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to reset the password for your account.
+Use the link below to choose a new password. It expires in %s and can only be used once.
+
+%s
+
+If you did not request a password reset, you can safely ignore this email.
+`, name, ttl, link),
+	}
+}
+
+// MagicLinkMessage builds the email containing a passwordless sign-in link
+func MagicLinkMessage(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Your Notes API sign-in link",
+		Body: fmt.Sprintf(`Hi %s,
+
+Use the link below to sign in to your account. It expires in %s, can only be used once
+and only works in the browser where you requested it.
+
+%s
+
+If you did not try to sign in, you can safely ignore this email.
+`, name, ttl, link),
+	}
+}
+
+// EmailVerificationMessage builds the email asking a user to confirm their address
+func EmailVerificationMessage(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Verify your Notes API email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Please confirm that this is your email address by opening the link below.
+The link expires in %s.
+
+%s
+
+If you did not create an account, you can safely ignore this email.
+`, name, ttl, link),
+	}
+}
+
+// EmailChangeMessage builds the email asking a user to confirm their new address
+func EmailChangeMessage(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Confirm your new Notes API email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+We received a request to change the email address of your account to this one.
+Open the link below while signed in to confirm the change. It expires in %s.
+
+%s
+
+If you did not request this change, you can safely ignore this email.
+`, name, ttl, link),
+	}
+}
+
+// EmailChangedMessage notifies the previous address that the account email was changed
+func EmailChangedMessage(to, name, newEmail string) Message {
+	return Message{
+		To:      to,
+		Subject: "Your Notes API email address was changed",
+		Body: fmt.Sprintf(`Hi %s,
+
+The email address of your account was changed to %s and all other sessions were signed out.
+
+If you did not make this change, please contact support immediately.
+`, name, newEmail),
+	}
+}
+
+// AccountDeletionMessage confirms that an account is scheduled for deletion
+func AccountDeletionMessage(to, name string, dueAt time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: "Your Notes API account will be deleted",
+		Body: fmt.Sprintf(`Hi %s,
+
+Your account and all of its notes will be permanently deleted on %s.
+All sessions have been signed out.
+
+Changed your mind? Simply sign in again before then to cancel the deletion.
+`, name, dueAt.UTC().Format("January 2, 2006 at 15:04 MST")),
+	}
+}
+
+// WorkspaceInvitationMessage builds the email inviting someone to join a workspace
+func WorkspaceInvitationMessage(to, inviterName, workspaceName, role, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("You have been invited to %s on Notes API", workspaceName),
+		Body: fmt.Sprintf(`Hi,
+
+%s invited you to join the workspace "%s" as %s.
+Open the link below to accept. If you do not have an account yet, you can create one with this email address.
+The link expires in %s.
+
+%s
+
+If you were not expecting this invitation, you can safely ignore this email.
+`, inviterName, workspaceName, role, ttl, link),
+	}
+}
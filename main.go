@@ -3,13 +3,17 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/expvar"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
 	"notes-api/config"
 	"notes-api/routes"
+	"notes-api/services"
+	"notes-api/utils"
 )
 
 func main() {
@@ -18,9 +22,25 @@ func main() {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	// Fail fast on missing or invalid token signing keys
+	if _, err := utils.LoadKeyRing(); err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+
 	// Initialize database
 	config.ConnectDB()
 
+	// Create built-in roles and grant the admin role to ADMIN_EMAILS
+	if err := services.EnsureDefaultRoles(); err != nil {
+		log.Fatal("Failed to set up roles:", err)
+	}
+
+	// Purge expired token revocations in the background
+	go services.Revocations.RunJanitor(time.Hour)
+
+	// Permanently delete accounts whose deletion grace period has ended
+	go services.RunAccountPurger(time.Hour)
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -37,11 +57,12 @@ func main() {
 
 	// Middleware
 	app.Use(logger.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-	}))
+	app.Use(cors.New(corsConfig()))
+
+	// Runtime and cache metrics at /debug/vars
+	if utils.GetEnvBool("METRICS_ENABLED", false) {
+		app.Use(expvar.New())
+	}
 
 	// Health check endpoint
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -63,3 +84,21 @@ func main() {
 	log.Printf("Server starting on port %s", port)
 	log.Fatal(app.Listen(":" + port))
 }
+
+// corsConfig allows any origin for token clients; listing origins in CORS_ALLOWED_ORIGINS
+// also lets those browser origins send cookie session credentials
+func corsConfig() cors.Config {
+	cfg := cors.Config{
+		AllowOrigins: "*",
+		AllowMethods: "GET,POST,PUT,PATCH,DELETE",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-CSRF-Token, X-Auth-Mode",
+	}
+
+	// Credentials must never be combined with a wildcard origin
+	if origins := utils.GetEnv("CORS_ALLOWED_ORIGINS", ""); origins != "" {
+		cfg.AllowOrigins = origins
+		cfg.AllowCredentials = true
+	}
+
+	return cfg
+}
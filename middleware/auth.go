@@ -1,20 +1,27 @@
 package middleware
 
 import (
+	"log"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"notes-api/config"
 	"notes-api/models"
+	"notes-api/services"
 	"notes-api/utils"
 )
 
-// JWTMiddleware validates JWT tokens and sets user context
+// JWTMiddleware validates JWT access tokens (sent as a Bearer token or the session cookie)
+// or personal access tokens and sets user context
 func JWTMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
+			// Browser clients may authenticate with the session cookie instead
+			if token := c.Cookies(AccessTokenCookie); token != "" {
+				return authenticateCookie(c, token)
+			}
+
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   true,
 				"message": "Authorization header is required",
@@ -38,30 +45,154 @@ func JWTMiddleware() fiber.Handler {
 			})
 		}
 
-		// Validate token
-		claims, err := utils.ValidateJWT(token)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   true,
-				"message": "Invalid or expired token",
-			})
+		// Personal access tokens are accepted next to JWTs
+		if strings.HasPrefix(token, models.PersonalAccessTokenPrefix) {
+			return authenticatePersonalAccessToken(c, token)
 		}
 
-		// Verify user exists in database
-		var user models.User
-		if err := config.GetDB().First(&user, claims.UserID).Error; err != nil {
+		return authenticateJWT(c, token)
+	}
+}
+
+// authenticateCookie validates the access token of a cookie session; authenticateJWT checks
+// its CSRF token once the session is known
+func authenticateCookie(c *fiber.Ctx, token string) error {
+	c.Locals("cookieSession", true)
+
+	return authenticateJWT(c, token)
+}
+
+// authenticateJWT validates an access token and its session and sets user context
+func authenticateJWT(c *fiber.Ctx, token string) error {
+	// Validate token
+	claims, err := utils.ValidateJWT(token)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired token",
+		})
+	}
+
+	// Cookies are sent automatically, so state-changing requests must prove same-origin
+	if IsCookieSession(c) && !VerifyCSRF(c, claims.SessionID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid CSRF token",
+		})
+	}
+
+	// Reject tokens revoked by logout
+	revoked, err := services.Revocations.IsRevoked(claims.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to verify token",
+		})
+	}
+	if revoked {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Token has been revoked",
+		})
+	}
+
+	// Verify user exists (served from the user cache when possible)
+	user, err := services.Users.Get(claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	// Reject disabled accounts
+	if user.IsDisabled() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Account is disabled",
+		})
+	}
+
+	// Reject tokens issued before the user's last password change
+	if claims.IssuedAt == nil || user.TokenIssuedBeforeWatermark(claims.IssuedAt.Time) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Token has been revoked",
+		})
+	}
+
+	// Verify the session behind the token has not been signed out (served from the user cache when possible)
+	session, err := services.Users.Session(user.ID, claims.SessionID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Session has been revoked",
+		})
+	}
+
+	// Record session activity
+	if err := services.TouchSession(session); err != nil {
+		log.Println("Failed to update session last seen:", err)
+	}
+
+	// Set user in context
+	c.Locals("user", user)
+	c.Locals("userID", claims.UserID)
+	c.Locals("claims", claims)
+	c.Locals("session", session)
+
+	return c.Next()
+}
+
+// authenticatePersonalAccessToken validates a personal access token and sets user and scope context
+func authenticatePersonalAccessToken(c *fiber.Ctx, token string) error {
+	// Validate token
+	pat, err := services.AuthenticatePersonalAccessToken(token)
+	if err != nil {
+		if err == services.ErrInvalidPersonalAccessToken {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   true,
-				"message": "User not found",
+				"message": "Invalid or expired token",
 			})
 		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to verify token",
+		})
+	}
 
-		// Set user in context
-		c.Locals("user", &user)
-		c.Locals("userID", claims.UserID)
+	// Verify user exists (served from the user cache when possible)
+	user, err := services.Users.Get(pat.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
 
-		return c.Next()
+	// Reject disabled accounts
+	if user.IsDisabled() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Account is disabled",
+		})
 	}
+
+	// Tokens stop working once the owner asked for their account to be deleted
+	if user.IsDeletionScheduled() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Account is scheduled for deletion",
+		})
+	}
+
+	// Set user and granted scopes in context
+	c.Locals("user", user)
+	c.Locals("userID", user.ID)
+	c.Locals("personalAccessToken", pat)
+	c.Locals("scopes", pat.ScopeList())
+
+	return c.Next()
 }
 
 // GetUserFromContext retrieves the authenticated user from context
@@ -81,3 +212,21 @@ func GetUserIDFromContext(c *fiber.Ctx) (uint, error) {
 	}
 	return userID, nil
 }
+
+// GetClaimsFromContext retrieves the validated JWT claims from context
+func GetClaimsFromContext(c *fiber.Ctx) (*utils.JWTClaims, error) {
+	claims, ok := c.Locals("claims").(*utils.JWTClaims)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Token claims not found in context")
+	}
+	return claims, nil
+}
+
+// GetSessionFromContext retrieves the current session from context
+func GetSessionFromContext(c *fiber.Ctx) (*models.Session, error) {
+	session, ok := c.Locals("session").(*models.Session)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Session not found in context")
+	}
+	return session, nil
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission guards routes with a permission granted through the user's roles.
+// Permissions are read from the access token, so personal access tokens never pass.
+// Must run after JWTMiddleware.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := GetClaimsFromContext(c)
+		if err != nil || !claims.HasPermission(permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "You do not have permission to perform this action",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireScope rejects personal access tokens that were not granted the scope.
+// Requests authenticated with a JWT carry the full rights of the user.
+// Must run after JWTMiddleware.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scopes, ok := c.Locals("scopes").([]string)
+		if !ok {
+			return c.Next()
+		}
+
+		for _, granted := range scopes {
+			if granted == scope {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Token is missing the required scope: " + scope,
+		})
+	}
+}
+
+// RequireSession rejects personal access tokens so a route can only be used
+// from an interactive login. Must run after JWTMiddleware.
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Locals("personalAccessToken") != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "This endpoint cannot be used with a personal access token",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"notes-api/utils"
+)
+
+// RequireVerifiedEmail rejects users who have not verified their email address
+// when REQUIRE_VERIFIED_EMAIL_FOR_NOTES is enabled; otherwise it is a no-op.
+// Must run after JWTMiddleware.
+func RequireVerifiedEmail() fiber.Handler {
+	enabled := utils.GetEnvBool("REQUIRE_VERIFIED_EMAIL_FOR_NOTES", false)
+
+	return func(c *fiber.Ctx) error {
+		if !enabled {
+			return c.Next()
+		}
+
+		user, err := GetUserFromContext(c)
+		if err != nil {
+			return err
+		}
+
+		if !user.IsEmailVerified() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Please verify your email address first",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// User statuses accepted by the admin user listing filter
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusDeleted  = "deleted"
+)
+
+// AdminUserResponse represents a user as seen by administrators
+type AdminUserResponse struct {
+	UserResponse
+	Disabled              bool       `json:"disabled"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	Deleted               bool       `json:"deleted"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+	NoteCount             int64      `json:"note_count"`
+}
+
+// PaginatedUsersResponse represents paginated admin user listing response
+type PaginatedUsersResponse struct {
+	Users       []AdminUserResponse `json:"users"`
+	Total       int64               `json:"total"`
+	Page        int                 `json:"page"`
+	PerPage     int                 `json:"per_page"`
+	TotalPages  int                 `json:"total_pages"`
+	HasNext     bool                `json:"has_next"`
+	HasPrevious bool                `json:"has_previous"`
+}
+
+// ToAdminResponse converts User to AdminUserResponse
+func (u *User) ToAdminResponse(noteCount int64) AdminUserResponse {
+	response := AdminUserResponse{
+		UserResponse:          u.ToResponse(),
+		Disabled:              u.IsDisabled(),
+		DisabledAt:            u.DisabledAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+		NoteCount:             noteCount,
+	}
+
+	if u.DeletedAt.Valid {
+		deletedAt := u.DeletedAt.Time
+		response.Deleted = true
+		response.DeletedAt = &deletedAt
+	}
+
+	return response
+}
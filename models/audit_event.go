@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audit event actions
+const (
+	AuditUserRegistered         = "user.registered"
+	AuditLoginSucceeded         = "auth.login"
+	AuditLoginFailed            = "auth.login_failed"
+	AuditLogout                 = "auth.logout"
+	AuditAccountLocked          = "auth.account_locked"
+	AuditIPLocked               = "auth.ip_locked"
+	AuditPasswordResetRequested = "auth.password_reset_requested"
+	AuditPasswordReset          = "auth.password_reset"
+	AuditMagicLinkRequested     = "auth.magic_link_requested"
+	AuditDeviceApproved         = "auth.device_approved"
+	AuditDeviceDenied           = "auth.device_denied"
+	AuditEmailVerified          = "auth.email_verified"
+	AuditTwoFactorEnabled       = "auth.2fa_enabled"
+	AuditTwoFactorDisabled      = "auth.2fa_disabled"
+	AuditProfileUpdated         = "profile.updated"
+	AuditPasswordChanged        = "profile.password_changed"
+	AuditEmailChangeRequested   = "profile.email_change_requested"
+	AuditEmailChanged           = "profile.email_changed"
+	AuditAccountExported        = "account.exported"
+	AuditAccountDeletion        = "account.deletion_scheduled"
+	AuditSessionRevoked         = "session.revoked"
+	AuditOtherSessionsRevoked   = "session.others_revoked"
+	AuditTokenCreated           = "token.created"
+	AuditTokenRevoked           = "token.revoked"
+	AuditNoteCreated            = "note.created"
+	AuditNoteUpdated            = "note.updated"
+	AuditNoteDeleted            = "note.deleted"
+	AuditNoteMoved              = "note.moved"
+	AuditNotebookCreated        = "notebook.created"
+	AuditNotebookUpdated        = "notebook.updated"
+	AuditNotebookMoved          = "notebook.moved"
+	AuditNotebookDeleted        = "notebook.deleted"
+	AuditWorkspaceCreated       = "workspace.created"
+	AuditWorkspaceUpdated       = "workspace.updated"
+	AuditWorkspaceDeleted       = "workspace.deleted"
+	AuditWorkspaceMemberAdded   = "workspace.member_added"
+	AuditWorkspaceMemberUpdated = "workspace.member_role_changed"
+	AuditWorkspaceMemberRemoved = "workspace.member_removed"
+	AuditInvitationCreated      = "workspace.invitation_created"
+	AuditInvitationRevoked      = "workspace.invitation_revoked"
+	AuditInvitationResent       = "workspace.invitation_resent"
+	AuditInvitationAccepted     = "workspace.invitation_accepted"
+	AuditRoleAssigned           = "admin.role_assigned"
+	AuditRoleRemoved            = "admin.role_removed"
+	AuditUserDisabled           = "admin.user_disabled"
+	AuditUserEnabled            = "admin.user_enabled"
+	AuditPasswordResetForced    = "admin.password_reset_forced"
+	AuditUserDeleted            = "admin.user_deleted"
+	AuditUserRestored           = "admin.user_restored"
+)
+
+// Audit event target types
+const (
+	AuditTargetUser      = "user"
+	AuditTargetNote      = "note"
+	AuditTargetNotebook  = "notebook"
+	AuditTargetSession   = "session"
+	AuditTargetToken     = "token"
+	AuditTargetWorkspace = "workspace"
+)
+
+// ErrAuditEventImmutable is returned when code tries to change a stored audit event
+var ErrAuditEventImmutable = errors.New("audit events cannot be modified")
+
+// AuditEvent is an append-only record of who did what to which resource, from where.
+// ActorID is the user performing the action (nil for anonymous requests such as failed logins),
+// UserID the account the event belongs to, which differs from the actor for admin actions.
+type AuditEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    *uint     `json:"actor_id" gorm:"index"`
+	UserID     *uint     `json:"user_id" gorm:"index"`
+	Email      string    `json:"email,omitempty" gorm:"size:100"`
+	Action     string    `json:"action" gorm:"not null;size:50;index"`
+	TargetType string    `json:"target_type,omitempty" gorm:"size:50"`
+	TargetID   string    `json:"target_id,omitempty" gorm:"size:64"`
+	IPAddress  string    `json:"ip_address" gorm:"size:45"`
+	UserAgent  string    `json:"user_agent" gorm:"size:255"`
+	Details    string    `json:"details,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// PaginatedAuditEventsResponse represents paginated audit event listing response
+type PaginatedAuditEventsResponse struct {
+	Events      []AuditEvent `json:"events"`
+	Total       int64        `json:"total"`
+	Page        int          `json:"page"`
+	PerPage     int          `json:"per_page"`
+	TotalPages  int          `json:"total_pages"`
+	HasNext     bool         `json:"has_next"`
+	HasPrevious bool         `json:"has_previous"`
+}
+
+// BeforeUpdate keeps audit events append-only
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+// BeforeDelete keeps audit events append-only
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceCodeGrantType is the grant_type devices use when polling for tokens (RFC 8628)
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Device authorization statuses
+const (
+	DeviceAuthorizationPending  = "pending"
+	DeviceAuthorizationApproved = "approved"
+	DeviceAuthorizationDenied   = "denied"
+	DeviceAuthorizationExpired  = "expired"
+	DeviceAuthorizationConsumed = "consumed"
+)
+
+// DeviceAuthorization represents a device authorization request (RFC 8628).
+// The device polls with the secret device code (stored hashed) while the user approves the
+// short user code from a signed-in browser. Interval grows each time the device polls too fast.
+type DeviceAuthorization struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	DeviceCodeHash string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	UserCode       string     `json:"user_code" gorm:"not null;size:16;index"`
+	ClientID       string     `json:"client_id" gorm:"size:100"`
+	UserID         *uint      `json:"-" gorm:"index"`
+	User           *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Interval       int        `json:"interval" gorm:"not null"`
+	LastPolledAt   *time.Time `json:"-"`
+	ApprovedAt     *time.Time `json:"approved_at"`
+	DeniedAt       *time.Time `json:"denied_at"`
+	ConsumedAt     *time.Time `json:"-"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Status reports the state of the authorization request
+func (d *DeviceAuthorization) Status() string {
+	switch {
+	case d.ConsumedAt != nil:
+		return DeviceAuthorizationConsumed
+	case time.Now().After(d.ExpiresAt):
+		return DeviceAuthorizationExpired
+	case d.DeniedAt != nil:
+		return DeviceAuthorizationDenied
+	case d.ApprovedAt != nil:
+		return DeviceAuthorizationApproved
+	default:
+		return DeviceAuthorizationPending
+	}
+}
+
+// DeviceCodeRequest represents the device authorization request payload (form or JSON encoded)
+type DeviceCodeRequest struct {
+	ClientID string `json:"client_id" form:"client_id" validate:"max=100"`
+}
+
+// DeviceTokenRequest represents the device access token request payload (form or JSON encoded)
+type DeviceTokenRequest struct {
+	GrantType  string `json:"grant_type" form:"grant_type"`
+	DeviceCode string `json:"device_code" form:"device_code"`
+	ClientID   string `json:"client_id" form:"client_id"`
+}
+
+// DeviceApprovalRequest represents the payload approving or denying a device
+type DeviceApprovalRequest struct {
+	UserCode string `json:"user_code" validate:"required"`
+}
+
+// DeviceCodeResponse is the RFC 8628 device authorization response
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceAuthorizationResponse describes a pending device to the user asked to approve it
+type DeviceAuthorizationResponse struct {
+	UserCode  string    `json:"user_code"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ToResponse converts a DeviceAuthorization to DeviceAuthorizationResponse
+func (d *DeviceAuthorization) ToResponse() DeviceAuthorizationResponse {
+	return DeviceAuthorizationResponse{
+		UserCode:  d.UserCode,
+		ClientID:  d.ClientID,
+		Status:    d.Status(),
+		ExpiresAt: d.ExpiresAt,
+		CreatedAt: d.CreatedAt,
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to an account at an external identity provider
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	User      User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Provider  string    `json:"provider" gorm:"not null;size:50;uniqueIndex:idx_identity_provider_subject"`
+	Subject   string    `json:"subject" gorm:"not null;size:255;uniqueIndex:idx_identity_provider_subject"`
+	Email     string    `json:"email" gorm:"size:100"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DirectoryRoleGrant records a role granted through directory groups, so later logins
+// only revoke roles the directory granted and keep the ones assigned by administrators
+type DirectoryRoleGrant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_directory_role_grant"`
+	User      User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Provider  string    `json:"provider" gorm:"not null;size:50;uniqueIndex:idx_directory_role_grant"`
+	RoleID    uint      `json:"role_id" gorm:"not null;uniqueIndex:idx_directory_role_grant"`
+	Role      Role      `json:"-" gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCLoginState holds the state, nonce and PKCE verifier of an OIDC login in progress
+type OIDCLoginState struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	StateHash    string    `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Provider     string    `json:"provider" gorm:"not null;size:50"`
+	Nonce        string    `json:"-" gorm:"not null;size:64"`
+	CodeVerifier string    `json:"-" gorm:"not null;size:128"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
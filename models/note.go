@@ -8,54 +8,73 @@ import (
 
 // Note represents a note in the system
 type Note struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"not null;size:200" validate:"required,min=1,max=200"`
-	Content   string         `json:"content" gorm:"type:text" validate:"required,min=1"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Title       string         `json:"title" gorm:"not null;size:200" validate:"required,min=1,max=200"`
+	Content     string         `json:"content" gorm:"type:text" validate:"required,min=1"`
+	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	User        User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	WorkspaceID *uint          `json:"workspace_id" gorm:"index"`
+	Workspace   *Workspace     `json:"-" gorm:"foreignKey:WorkspaceID"`
+	NotebookID  *uint          `json:"notebook_id" gorm:"index"`
+	Notebook    *Notebook      `json:"-" gorm:"foreignKey:NotebookID;constraint:OnDelete:SET NULL"`
+	Tags        []Tag          `json:"-" gorm:"many2many:note_tags;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // NoteCreateRequest represents the note creation request payload
 type NoteCreateRequest struct {
-	Title   string `json:"title" validate:"required,min=1,max=200"`
-	Content string `json:"content" validate:"required,min=1"`
+	Title      string   `json:"title" validate:"required,min=1,max=200"`
+	Content    string   `json:"content" validate:"required,min=1"`
+	Tags       []string `json:"tags"`
+	NotebookID *uint    `json:"notebook_id"`
 }
 
-// NoteUpdateRequest represents the note update request payload
+// NoteUpdateRequest represents the note update request payload.
+// Omitting tags keeps the note's tags; an empty list removes them.
 type NoteUpdateRequest struct {
-	Title   string `json:"title" validate:"required,min=1,max=200"`
-	Content string `json:"content" validate:"required,min=1"`
+	Title   string   `json:"title" validate:"required,min=1,max=200"`
+	Content string   `json:"content" validate:"required,min=1"`
+	Tags    []string `json:"tags"`
 }
 
 // NoteResponse represents the note response
 type NoteResponse struct {
-	ID        uint         `json:"id"`
-	Title     string       `json:"title"`
-	Content   string       `json:"content"`
-	UserID    uint         `json:"user_id"`
-	User      UserResponse `json:"user,omitempty"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+	ID          uint         `json:"id"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	UserID      uint         `json:"user_id"`
+	User        UserResponse `json:"user,omitempty"`
+	WorkspaceID *uint        `json:"workspace_id"`
+	NotebookID  *uint        `json:"notebook_id"`
+	Tags        []string     `json:"tags"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 // ToResponse converts Note to NoteResponse
 func (n *Note) ToResponse() NoteResponse {
 	response := NoteResponse{
-		ID:        n.ID,
-		Title:     n.Title,
-		Content:   n.Content,
-		UserID:    n.UserID,
-		CreatedAt: n.CreatedAt,
-		UpdatedAt: n.UpdatedAt,
+		ID:          n.ID,
+		Title:       n.Title,
+		Content:     n.Content,
+		UserID:      n.UserID,
+		WorkspaceID: n.WorkspaceID,
+		NotebookID:  n.NotebookID,
+		Tags:        make([]string, 0, len(n.Tags)),
+		CreatedAt:   n.CreatedAt,
+		UpdatedAt:   n.UpdatedAt,
 	}
-	
+
+	for _, tag := range n.Tags {
+		response.Tags = append(response.Tags, tag.Name)
+	}
+
 	if n.User.ID != 0 {
 		response.User = n.User.ToResponse()
 	}
-	
+
 	return response
 }
 
@@ -68,4 +87,4 @@ type PaginatedNotesResponse struct {
 	TotalPages  int            `json:"total_pages"`
 	HasNext     bool           `json:"has_next"`
 	HasPrevious bool           `json:"has_previous"`
-}
\ No newline at end of file
+}
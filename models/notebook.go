@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// Notebook is a folder for notes. Notebooks nest through ParentID and live in the same
+// workspace (or personal workspace) as the notes they hold.
+type Notebook struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"not null;size:100"`
+	ParentID    *uint      `json:"parent_id" gorm:"index"`
+	Parent      *Notebook  `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	WorkspaceID *uint      `json:"workspace_id" gorm:"index"`
+	Workspace   *Workspace `json:"-" gorm:"foreignKey:WorkspaceID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NotebookCreateRequest represents the notebook creation request payload
+type NotebookCreateRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// NotebookUpdateRequest represents the notebook rename request payload
+type NotebookUpdateRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// NotebookMoveRequest represents the payload moving a notebook (with its subtree); a null parent moves it to the top level
+type NotebookMoveRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// NoteMoveRequest represents the payload moving a note; a null notebook takes it out of all notebooks
+type NoteMoveRequest struct {
+	NotebookID *uint `json:"notebook_id"`
+}
+
+// NotebookResponse represents a notebook with its direct note count and nested notebooks
+type NotebookResponse struct {
+	ID          uint               `json:"id"`
+	Name        string             `json:"name"`
+	ParentID    *uint              `json:"parent_id"`
+	UserID      uint               `json:"user_id"`
+	WorkspaceID *uint              `json:"workspace_id"`
+	NoteCount   int64              `json:"note_count"`
+	Children    []NotebookResponse `json:"children"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// ToResponse converts Notebook to NotebookResponse without children
+func (n *Notebook) ToResponse(noteCount int64) NotebookResponse {
+	return NotebookResponse{
+		ID:          n.ID,
+		Name:        n.Name,
+		ParentID:    n.ParentID,
+		UserID:      n.UserID,
+		WorkspaceID: n.WorkspaceID,
+		NoteCount:   noteCount,
+		Children:    []NotebookResponse{},
+		CreatedAt:   n.CreatedAt,
+		UpdatedAt:   n.UpdatedAt,
+	}
+}
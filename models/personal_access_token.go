@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Personal access token scopes
+const (
+	ScopeNotesRead   = "notes:read"
+	ScopeNotesWrite  = "notes:write"
+	ScopeProfileRead = "profile:read"
+)
+
+// PersonalAccessTokenPrefix marks bearer tokens that are personal access tokens rather than JWTs
+const PersonalAccessTokenPrefix = "nap_"
+
+// AllScopes lists every scope that can be granted to a personal access token
+var AllScopes = []string{ScopeNotesRead, ScopeNotesWrite, ScopeProfileRead}
+
+// PersonalAccessToken represents a named, revocable token for scripts and integrations
+type PersonalAccessToken struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Name        string     `json:"name" gorm:"not null;size:100"`
+	TokenPrefix string     `json:"token_prefix" gorm:"not null;size:16"`
+	TokenHash   string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Scopes      string     `json:"-" gorm:"not null;size:255"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// PersonalAccessTokenCreateRequest represents the personal access token creation payload
+type PersonalAccessTokenCreateRequest struct {
+	Name          string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// PersonalAccessTokenResponse represents the personal access token response
+type PersonalAccessTokenResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ScopeList returns the granted scopes
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// IsActive reports whether the token can still be used
+func (t *PersonalAccessToken) IsActive() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt))
+}
+
+// ToResponse converts PersonalAccessToken to PersonalAccessTokenResponse
+func (t *PersonalAccessToken) ToResponse() PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.ScopeList(),
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// IsValidScope reports whether the scope can be granted
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
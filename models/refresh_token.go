@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken represents a long-lived, server-stored refresh token.
+// Tokens are rotated on every use; all tokens descending from the same login
+// share a FamilyID so the whole chain can be revoked when reuse is detected.
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	User         User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	FamilyID     string     `json:"family_id" gorm:"not null;size:36;index"`
+	TokenHash    string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt       *time.Time `json:"used_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RefreshTokenRequest represents the token refresh request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// IsActive reports whether the refresh token can still be exchanged
+func (t *RefreshToken) IsActive() bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && time.Now().Before(t.ExpiresAt)
+}
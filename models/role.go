@@ -0,0 +1,90 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Permissions that can be granted through roles
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersManage = "users:manage"
+	PermissionRolesManage = "roles:manage"
+	PermissionAuditRead   = "audit:read"
+)
+
+// RoleAdmin is the built-in administrator role holding every permission
+const RoleAdmin = "admin"
+
+// AllPermissions lists every permission known to the application
+var AllPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersManage,
+	PermissionRolesManage,
+	PermissionAuditRead,
+}
+
+// Role represents a named set of permissions assigned to users
+type Role struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;size:50;uniqueIndex"`
+	Description string    `json:"description" gorm:"size:255"`
+	Permissions string    `json:"-" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RoleResponse represents the role response
+type RoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleAssignRequest represents the role assignment request payload
+type RoleAssignRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// PermissionList returns the permissions granted by the role
+func (r *Role) PermissionList() []string {
+	return strings.Fields(r.Permissions)
+}
+
+// ToResponse converts Role to RoleResponse
+func (r *Role) ToResponse() RoleResponse {
+	return RoleResponse{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.PermissionList(),
+	}
+}
+
+// RoleNames returns the names of the user's roles (requires Roles to be loaded)
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PermissionList returns the union of permissions granted by the user's roles (requires Roles to be loaded)
+func (u *User) PermissionList() []string {
+	seen := make(map[string]bool)
+	permissions := []string{}
+	for _, role := range u.Roles {
+		for _, permission := range role.PermissionList() {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// Tag is a label a user attaches to notes. Tags are private to their owner: on a shared
+// workspace note every member sees and filters by their own tags only.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_tag_user_name"`
+	User      User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Name      string    `json:"name" gorm:"not null;size:50;uniqueIndex:idx_tag_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TagCreateRequest represents the tag creation request payload
+type TagCreateRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+// TagRenameRequest represents the tag rename request payload
+type TagRenameRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+// TagMergeRequest represents the payload merging a tag into another one
+type TagMergeRequest struct {
+	IntoTagID uint `json:"into_tag_id"`
+}
+
+// TagResponse represents a tag with the number of notes carrying it
+type TagResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	NoteCount int64     `json:"note_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ToResponse converts Tag to TagResponse
+func (t *Tag) ToResponse(noteCount int64) TagResponse {
+	return TagResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		NoteCount: noteCount,
+		CreatedAt: t.CreatedAt,
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode represents a single-use two-factor recovery code, stored as an HMAC keyed with the server secret
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_recovery_code_user_hash"`
+	User      User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64;uniqueIndex:idx_recovery_code_user_hash"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorConfirmRequest represents the two-factor enrollment confirmation payload
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorDisableRequest represents the two-factor disable request payload.
+// Password is only required for accounts with a local password.
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TwoFactorVerifyRequest represents the second login step payload.
+// Code may be either a TOTP code or an unused recovery code.
+type TwoFactorVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -3,27 +3,45 @@ package models
 import (
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"notes-api/utils"
 )
 
-// User represents a user in the system
+// User represents a user in the system.
+// Tokens issued before TokensValidAfter are rejected (bumped on password change).
+// Disabled users cannot sign in and PasswordResetRequired blocks password logins until a reset.
+// Accounts with DeletionDueAt set are purged at that time unless the user signs in again.
+// PasswordUnset marks accounts created from an external identity whose random password nobody knows.
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"not null;size:100" validate:"required,min=2,max=100"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null;size:100" validate:"required,email"`
-	Password  string         `json:"-" gorm:"not null" validate:"required,min=6"`
-	Notes     []Note         `json:"notes,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                    uint           `json:"id" gorm:"primaryKey"`
+	Name                  string         `json:"name" gorm:"not null;size:100" validate:"required,min=2,max=100"`
+	Email                 string         `json:"email" gorm:"uniqueIndex;not null;size:100" validate:"required,email"`
+	Password              string         `json:"-" gorm:"not null" validate:"required,min=6"`
+	Notes                 []Note         `json:"notes,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Roles                 []Role         `json:"roles,omitempty" gorm:"many2many:user_roles;constraint:OnDelete:CASCADE"`
+	EmailVerifiedAt       *time.Time     `json:"email_verified_at"`
+	TokensValidAfter      *time.Time     `json:"-"`
+	TOTPSecret            string         `json:"-" gorm:"size:64"`
+	TOTPEnabledAt         *time.Time     `json:"-"`
+	TOTPLastStep          int64          `json:"-"`
+	DisabledAt            *time.Time     `json:"-"`
+	PasswordResetRequired bool           `json:"-" gorm:"not null;default:false"`
+	PasswordUnset         bool           `json:"-" gorm:"not null;default:false"`
+	DeletionDueAt         *time.Time     `json:"-" gorm:"index"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
+
+	passwordRehashed bool
 }
 
 // UserRegisterRequest represents the registration request payload
 type UserRegisterRequest struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,password=Email"`
+	// InvitationToken optionally joins a workspace the email address was invited to
+	InvitationToken string `json:"invitation_token"`
 }
 
 // UserLoginRequest represents the login request payload
@@ -34,41 +52,153 @@ type UserLoginRequest struct {
 
 // UserResponse represents the user response (without password)
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	TwoFactor       bool       `json:"two_factor_enabled"`
+	Roles           []string   `json:"roles,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ProfileUpdateRequest represents the profile update request payload
+type ProfileUpdateRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+// ChangePasswordRequest represents the password change request payload.
+// CurrentPassword is only required for accounts with a local password; accounts created
+// from an external identity confirm with a two-factor code or a recent login instead.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
 }
 
-// HashPassword hashes the user's password using bcrypt
+// ChangeEmailRequest represents the email change request payload.
+// CurrentPassword is only required for accounts with a local password, as for ChangePasswordRequest.
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" validate:"required,email,max=100"`
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+// DeleteAccountRequest represents the account deletion request payload.
+// CurrentPassword is only required for accounts with a local password, as for ChangePasswordRequest.
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+// ConfirmEmailChangeRequest represents the email change confirmation payload
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// VerifyEmailRequest represents the email verification request payload
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// HashPassword hashes the user's password with the configured hasher (argon2id by default)
 func (u *User) HashPassword() error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(u.Password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
-// CheckPassword compares the provided password with the hashed password
+// SetPassword hashes a new password and invalidates all previously issued tokens
+func (u *User) SetPassword(password string) error {
+	u.Password = password
+	if err := u.HashPassword(); err != nil {
+		return err
+	}
+	u.PasswordUnset = false
+	u.RevokeIssuedTokens()
+	return nil
+}
+
+// RevokeIssuedTokens bumps the watermark so tokens issued until now are rejected.
+// JWT timestamps have second precision, so the watermark is truncated accordingly.
+func (u *User) RevokeIssuedTokens() {
+	now := time.Now().Truncate(time.Second)
+	u.TokensValidAfter = &now
+}
+
+// TokenIssuedBeforeWatermark reports whether a token issued at the given time has been invalidated
+func (u *User) TokenIssuedBeforeWatermark(issuedAt time.Time) bool {
+	return u.TokensValidAfter != nil && issuedAt.Before(*u.TokensValidAfter)
+}
+
+// CheckPassword compares the provided password with the hashed password.
+// Hashes using an outdated algorithm or parameters are upgraded in memory; callers
+// persist the new hash when PasswordRehashed reports true.
 func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+	ok, needsRehash := utils.VerifyPassword(password, u.Password)
+	if !ok {
+		return false
+	}
+
+	if needsRehash {
+		if hashedPassword, err := utils.HashPassword(password); err == nil {
+			u.Password = hashedPassword
+			u.passwordRehashed = true
+		}
+	}
+	return true
+}
+
+// HasLocalPassword reports whether the user ever chose a password for this account
+func (u *User) HasLocalPassword() bool {
+	return !u.PasswordUnset
+}
+
+// PasswordRehashed reports whether CheckPassword upgraded the stored hash
+func (u *User) PasswordRehashed() bool {
+	return u.passwordRehashed
+}
+
+// IsTwoFactorEnabled reports whether the user completed TOTP enrollment
+func (u *User) IsTwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != ""
+}
+
+// IsDisabled reports whether an administrator disabled the account
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsDeletionScheduled reports whether the user asked for their account to be deleted
+func (u *User) IsDeletionScheduled() bool {
+	return u.DeletionDueAt != nil
 }
 
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:              u.ID,
+		Name:            u.Name,
+		Email:           u.Email,
+		EmailVerified:   u.IsEmailVerified(),
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		TwoFactor:       u.IsTwoFactorEnabled(),
+		Roles:           u.RoleNames(),
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
 	}
 }
 
+// IsEmailVerified reports whether the user confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // BeforeCreate is a GORM hook that runs before creating a user
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return u.HashPassword()
-}
\ No newline at end of file
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Workspace member roles
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleMember = "member"
+)
+
+// Workspace is a shared space whose notes are visible to all of its members.
+// Notes without a workspace live in their author's implicit personal workspace.
+type Workspace struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	Name      string            `json:"name" gorm:"not null;size:100"`
+	Members   []WorkspaceMember `json:"-" gorm:"foreignKey:WorkspaceID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	DeletedAt gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// WorkspaceMember grants a user a role in a workspace
+type WorkspaceMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"not null;uniqueIndex:idx_workspace_member"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_workspace_member;index"`
+	User        User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Role        string    `json:"role" gorm:"not null;size:20"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceCreateRequest represents the workspace creation request payload
+type WorkspaceCreateRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// WorkspaceUpdateRequest represents the workspace update request payload
+type WorkspaceUpdateRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// WorkspaceMemberUpdateRequest represents the request payload for changing a member's role
+type WorkspaceMemberUpdateRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// WorkspaceResponse represents a workspace as seen by one of its members
+type WorkspaceResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	MemberCount int64     `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WorkspaceMemberResponse represents the workspace member response
+type WorkspaceMemberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ToResponse converts Workspace to WorkspaceResponse for a member with the given role
+func (w *Workspace) ToResponse(role string, memberCount int64) WorkspaceResponse {
+	return WorkspaceResponse{
+		ID:          w.ID,
+		Name:        w.Name,
+		Role:        role,
+		MemberCount: memberCount,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+// ToResponse converts WorkspaceMember to WorkspaceMemberResponse (requires User to be loaded)
+func (m *WorkspaceMember) ToResponse() WorkspaceMemberResponse {
+	return WorkspaceMemberResponse{
+		UserID:   m.UserID,
+		Name:     m.User.Name,
+		Email:    m.User.Email,
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt,
+	}
+}
+
+// CanManageMembers reports whether the role may add, remove and rename things in the workspace
+func (m *WorkspaceMember) CanManageMembers() bool {
+	return m.Role == WorkspaceRoleOwner || m.Role == WorkspaceRoleAdmin
+}
+
+// IsOwner reports whether the member owns the workspace
+func (m *WorkspaceMember) IsOwner() bool {
+	return m.Role == WorkspaceRoleOwner
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+)
+
+// Workspace invitation statuses
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// WorkspaceInvitation invites an email address to join a workspace with a role.
+// Only the SHA-256 hash of the emailed link token is stored.
+type WorkspaceInvitation struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint       `json:"workspace_id" gorm:"not null;index"`
+	Workspace   Workspace  `json:"-" gorm:"foreignKey:WorkspaceID;constraint:OnDelete:CASCADE"`
+	Email       string     `json:"email" gorm:"not null;size:100;index"`
+	Role        string     `json:"role" gorm:"not null;size:20"`
+	TokenHash   string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	InvitedByID *uint      `json:"invited_by_id" gorm:"index"`
+	InvitedBy   *User      `json:"-" gorm:"foreignKey:InvitedByID;constraint:OnDelete:SET NULL"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	LastSentAt  time.Time  `json:"last_sent_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// WorkspaceInvitationCreateRequest represents the invitation creation request payload
+type WorkspaceInvitationCreateRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// InvitationTokenRequest represents a request carrying an invitation link token
+type InvitationTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// WorkspaceInvitationResponse represents the invitation response
+type WorkspaceInvitationResponse struct {
+	ID          uint       `json:"id"`
+	WorkspaceID uint       `json:"workspace_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	Status      string     `json:"status"`
+	InvitedByID *uint      `json:"invited_by_id"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastSentAt  time.Time  `json:"last_sent_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// InvitationPreviewResponse describes an invitation to the person holding its link
+type InvitationPreviewResponse struct {
+	WorkspaceName string    `json:"workspace_name"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	AccountExists bool      `json:"account_exists"`
+}
+
+// Status returns whether the invitation is pending, accepted, revoked or expired
+func (i *WorkspaceInvitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
+
+// ToResponse converts WorkspaceInvitation to WorkspaceInvitationResponse
+func (i *WorkspaceInvitation) ToResponse() WorkspaceInvitationResponse {
+	return WorkspaceInvitationResponse{
+		ID:          i.ID,
+		WorkspaceID: i.WorkspaceID,
+		Email:       i.Email,
+		Role:        i.Role,
+		Status:      i.Status(),
+		InvitedByID: i.InvitedByID,
+		ExpiresAt:   i.ExpiresAt,
+		LastSentAt:  i.LastSentAt,
+		AcceptedAt:  i.AcceptedAt,
+		CreatedAt:   i.CreatedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package oidc
+
+import (
+	"log"
+	"strings"
+
+	"notes-api/utils"
+)
+
+// LoadProvidersFromEnv builds the providers listed in OIDC_PROVIDERS.
+// Each provider NAME is configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and optionally OIDC_<NAME>_SCOPES.
+func LoadProvidersFromEnv() map[string]*Provider {
+	providers := make(map[string]*Provider)
+
+	for _, name := range strings.Split(utils.GetEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := NewProvider(Config{
+			Name:         name,
+			Issuer:       utils.GetEnv(prefix+"ISSUER", ""),
+			ClientID:     utils.GetEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: utils.GetEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  utils.GetEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       strings.Fields(utils.GetEnv(prefix+"SCOPES", "openid email profile")),
+		})
+
+		if provider.config.Issuer == "" || provider.config.ClientID == "" || provider.config.RedirectURL == "" {
+			log.Printf("Skipping OIDC provider %q: issuer, client ID and redirect URL are required", name)
+			continue
+		}
+
+		providers[name] = provider
+	}
+
+	return providers
+}
@@ -0,0 +1,351 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "notes-api"
+	testKeyID    = "test-key"
+	testNonce    = "nonce-123"
+)
+
+// mockProvider is an OpenID Connect provider serving discovery, JWKS and token endpoints
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// idToken is returned by the token endpoint
+	idToken string
+	// verifier is the PKCE verifier the token endpoint expects
+	verifier string
+	// issuer overrides the issuer announced by discovery
+	issuer string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := m.issuer
+		if issuer == "" {
+			issuer = m.server.URL
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                issuer,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256", "HS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": testKeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != "good-code" ||
+			r.PostFormValue("code_verifier") != m.verifier {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", TokenType: "Bearer", IDToken: m.idToken})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+// provider returns a client registered at the mock provider
+func (m *mockProvider) provider() *Provider {
+	return NewProvider(Config{
+		Name:        "mock",
+		Issuer:      m.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"openid", "email"},
+	})
+}
+
+// claims returns valid ID token claims for the mock provider
+func (m *mockProvider) claims() *IDTokenClaims {
+	now := time.Now()
+	return &IDTokenClaims{
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Nonce:         testNonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.server.URL,
+			Subject:   "alice",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+}
+
+// sign signs claims with the provider's RSA key
+func (m *mockProvider) sign(t *testing.T, claims *IDTokenClaims, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatalf("sign ID token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	m := newMockProvider(t)
+
+	tests := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: func() string { return m.sign(t, m.claims(), testKeyID) },
+			nonce: testNonce,
+		},
+		{
+			name:  "valid without kid",
+			token: func() string { return m.sign(t, m.claims(), "") },
+			nonce: testNonce,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := m.claims()
+				claims.Issuer = "https://evil.example.com"
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := m.claims()
+				claims.Audience = jwt.ClaimStrings{"other-client"}
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "several audiences without authorized party",
+			token: func() string {
+				claims := m.claims()
+				claims.Audience = jwt.ClaimStrings{testClientID, "other-client"}
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "several audiences authorized to us",
+			token: func() string {
+				claims := m.claims()
+				claims.Audience = jwt.ClaimStrings{testClientID, "other-client"}
+				claims.AuthorizedBy = testClientID
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce: testNonce,
+		},
+		{
+			name:    "nonce mismatch",
+			token:   func() string { return m.sign(t, m.claims(), testKeyID) },
+			nonce:   "other-nonce",
+			wantErr: true,
+		},
+		{
+			name: "missing nonce",
+			token: func() string {
+				claims := m.claims()
+				claims.Nonce = ""
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   "",
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := m.claims()
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "missing expiry",
+			token: func() string {
+				claims := m.claims()
+				claims.ExpiresAt = nil
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "issued in the future",
+			token: func() string {
+				claims := m.claims()
+				claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+				return m.sign(t, claims, testKeyID)
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "HMAC algorithm",
+			token: func() string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, m.claims()).SignedString([]byte(testClientID))
+				if err != nil {
+					t.Fatalf("sign ID token: %v", err)
+				}
+				return signed
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "none algorithm",
+			token: func() string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, m.claims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatalf("sign ID token: %v", err)
+				}
+				return signed
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "unknown key",
+			token: func() string {
+				other, err := rsa.GenerateKey(rand.Reader, 2048)
+				if err != nil {
+					t.Fatalf("generate key: %v", err)
+				}
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims())
+				token.Header["kid"] = "other-key"
+				signed, err := token.SignedString(other)
+				if err != nil {
+					t.Fatalf("sign ID token: %v", err)
+				}
+				return signed
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+		{
+			name: "bad signature",
+			token: func() string {
+				signed := m.sign(t, m.claims(), testKeyID)
+				return signed[:len(signed)-4] + "AAAA"
+			},
+			nonce:   testNonce,
+			wantErr: true,
+		},
+	}
+
+	provider := m.provider()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.VerifyIDToken(context.Background(), tt.token(), tt.nonce)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Fatalf("VerifyIDToken() error = %v, want ErrInvalidIDToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken() error = %v", err)
+			}
+			if claims.Subject != "alice" || claims.Email != "alice@example.com" || !bool(claims.EmailVerified) {
+				t.Errorf("VerifyIDToken() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	m.verifier = "verifier-abc"
+	m.idToken = m.sign(t, m.claims(), testKeyID)
+	provider := m.provider()
+
+	tests := []struct {
+		name     string
+		code     string
+		verifier string
+		wantErr  bool
+	}{
+		{name: "valid code and verifier", code: "good-code", verifier: "verifier-abc"},
+		{name: "wrong verifier", code: "good-code", verifier: "other-verifier", wantErr: true},
+		{name: "wrong code", code: "bad-code", verifier: "verifier-abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := provider.Exchange(context.Background(), tt.code, tt.verifier)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Exchange() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			if _, err := provider.VerifyIDToken(context.Background(), tokens.IDToken, testNonce); err != nil {
+				t.Fatalf("VerifyIDToken() of exchanged token error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	m.issuer = "https://evil.example.com"
+
+	if _, err := m.provider().AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("AuthCodeURL() succeeded with a mismatched issuer")
+	}
+}
@@ -1,16 +1,37 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"notes-api/authbackend"
 	"notes-api/handlers"
+	"notes-api/mailer"
 	"notes-api/middleware"
+	"notes-api/models"
+	"notes-api/oidc"
+	"notes-api/services"
 )
 
 // SetupRoutes configures all application routes
 func SetupRoutes(app *fiber.App) {
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler()
+	mail := mailer.NewFromEnv()
+	loginThrottle := services.NewLoginThrottleFromEnv()
+	go loginThrottle.RunJanitor(time.Hour)
+	authHandler := handlers.NewAuthHandler(mail, oidc.LoadProvidersFromEnv(), loginThrottle, authbackend.NewFromEnv())
 	notesHandler := handlers.NewNotesHandler()
+	tagsHandler := handlers.NewTagsHandler()
+	notebooksHandler := handlers.NewNotebooksHandler()
+	sessionsHandler := handlers.NewSessionsHandler()
+	tokensHandler := handlers.NewTokensHandler()
+	rolesHandler := handlers.NewRolesHandler()
+	adminUsersHandler := handlers.NewAdminUsersHandler(mail)
+	auditHandler := handlers.NewAuditHandler()
+	workspacesHandler := handlers.NewWorkspacesHandler(mail)
+
+	// Public keys for verifying access tokens
+	app.Get("/.well-known/jwks.json", handlers.JWKS)
 
 	// API version 1 group
 	api := app.Group("/api/v1")
@@ -19,18 +40,149 @@ func SetupRoutes(app *fiber.App) {
 	auth := api.Group("/auth")
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
+	auth.Post("/refresh", authHandler.Refresh)
+	auth.Post("/forgot-password", authHandler.ForgotPassword)
+	auth.Post("/reset-password", authHandler.ResetPassword)
+	auth.Post("/verify-email", authHandler.VerifyEmail)
+	auth.Post("/magic-link", authHandler.RequestMagicLink)
+	auth.Post("/magic-link/verify", authHandler.VerifyMagicLink)
+	auth.Post("/2fa/verify", authHandler.VerifyTwoFactor)
+	auth.Post("/device/code", authHandler.DeviceCode)
+	auth.Post("/device/token", authHandler.DeviceToken)
+	auth.Get("/oidc/providers", authHandler.OIDCProviders)
+	auth.Get("/oidc/:provider/login", authHandler.OIDCLogin)
+	auth.Get("/oidc/:provider/callback", authHandler.OIDCCallback)
+
+	// Workspace invitation lookup (public, so invitees can choose to sign in or register)
+	api.Post("/invitations/preview", workspacesHandler.PreviewInvitation)
 
-	// Protected routes (require JWT authentication)
+	// Protected routes (require a JWT or a personal access token)
 	protected := api.Group("", middleware.JWTMiddleware())
 
+	// Routes usable with personal access tokens are registered first; each enforces its scope
+	canReadProfile := middleware.RequireScope(models.ScopeProfileRead)
+	canReadNotes := middleware.RequireScope(models.ScopeNotesRead)
+	canWriteNotes := middleware.RequireScope(models.ScopeNotesWrite)
+
 	// User profile route
-	protected.Get("/profile", authHandler.Profile)
+	protected.Get("/profile", canReadProfile, authHandler.Profile)
 
 	// Notes routes (all protected)
 	notes := protected.Group("/notes")
-	notes.Post("/", notesHandler.CreateNote)           // POST /api/v1/notes
-	notes.Get("/", notesHandler.GetNotes)              // GET /api/v1/notes
-	notes.Get("/:id", notesHandler.GetNote)            // GET /api/v1/notes/:id
-	notes.Put("/:id", notesHandler.UpdateNote)         // PUT /api/v1/notes/:id
-	notes.Delete("/:id", notesHandler.DeleteNote)      // DELETE /api/v1/notes/:id
+	requireVerified := middleware.RequireVerifiedEmail()
+	notes.Post("/", canWriteNotes, requireVerified, notesHandler.CreateNote) // POST /api/v1/notes
+	notes.Get("/", canReadNotes, notesHandler.GetNotes)                      // GET /api/v1/notes
+	notes.Get("/:id", canReadNotes, notesHandler.GetNote)                    // GET /api/v1/notes/:id
+	notes.Put("/:id", canWriteNotes, notesHandler.UpdateNote)                // PUT /api/v1/notes/:id
+	notes.Post("/:id/move", canWriteNotes, notesHandler.MoveNote)            // POST /api/v1/notes/:id/move
+	notes.Delete("/:id", canWriteNotes, notesHandler.DeleteNote)             // DELETE /api/v1/notes/:id
+
+	// Workspace notes routes (same handlers, scoped to a shared workspace)
+	workspaceNotes := protected.Group("/workspaces/:workspaceId/notes")
+	workspaceNotes.Post("/", canWriteNotes, requireVerified, notesHandler.CreateNote) // POST /api/v1/workspaces/:workspaceId/notes
+	workspaceNotes.Get("/", canReadNotes, notesHandler.GetNotes)                      // GET /api/v1/workspaces/:workspaceId/notes
+	workspaceNotes.Get("/:id", canReadNotes, notesHandler.GetNote)                    // GET /api/v1/workspaces/:workspaceId/notes/:id
+	workspaceNotes.Put("/:id", canWriteNotes, notesHandler.UpdateNote)                // PUT /api/v1/workspaces/:workspaceId/notes/:id
+	workspaceNotes.Post("/:id/move", canWriteNotes, notesHandler.MoveNote)            // POST /api/v1/workspaces/:workspaceId/notes/:id/move
+	workspaceNotes.Delete("/:id", canWriteNotes, notesHandler.DeleteNote)             // DELETE /api/v1/workspaces/:workspaceId/notes/:id
+
+	// Notebook routes (nested folders in the personal workspace)
+	notebooks := protected.Group("/notebooks")
+	notebooks.Post("/", canWriteNotes, notebooksHandler.CreateNotebook)       // POST /api/v1/notebooks
+	notebooks.Get("/", canReadNotes, notebooksHandler.GetNotebooks)           // GET /api/v1/notebooks
+	notebooks.Get("/:id", canReadNotes, notebooksHandler.GetNotebook)         // GET /api/v1/notebooks/:id
+	notebooks.Patch("/:id", canWriteNotes, notebooksHandler.RenameNotebook)   // PATCH /api/v1/notebooks/:id
+	notebooks.Post("/:id/move", canWriteNotes, notebooksHandler.MoveNotebook) // POST /api/v1/notebooks/:id/move
+	notebooks.Delete("/:id", canWriteNotes, notebooksHandler.DeleteNotebook)  // DELETE /api/v1/notebooks/:id
+
+	// Workspace notebook routes (same handlers, scoped to a shared workspace)
+	workspaceNotebooks := protected.Group("/workspaces/:workspaceId/notebooks")
+	workspaceNotebooks.Post("/", canWriteNotes, notebooksHandler.CreateNotebook)       // POST /api/v1/workspaces/:workspaceId/notebooks
+	workspaceNotebooks.Get("/", canReadNotes, notebooksHandler.GetNotebooks)           // GET /api/v1/workspaces/:workspaceId/notebooks
+	workspaceNotebooks.Get("/:id", canReadNotes, notebooksHandler.GetNotebook)         // GET /api/v1/workspaces/:workspaceId/notebooks/:id
+	workspaceNotebooks.Patch("/:id", canWriteNotes, notebooksHandler.RenameNotebook)   // PATCH /api/v1/workspaces/:workspaceId/notebooks/:id
+	workspaceNotebooks.Post("/:id/move", canWriteNotes, notebooksHandler.MoveNotebook) // POST /api/v1/workspaces/:workspaceId/notebooks/:id/move
+	workspaceNotebooks.Delete("/:id", canWriteNotes, notebooksHandler.DeleteNotebook)  // DELETE /api/v1/workspaces/:workspaceId/notebooks/:id
+
+	// Tag routes (the user's own tags across all workspaces)
+	tags := protected.Group("/tags")
+	tags.Get("/", canReadNotes, tagsHandler.GetTags)             // GET /api/v1/tags
+	tags.Post("/", canWriteNotes, tagsHandler.CreateTag)         // POST /api/v1/tags
+	tags.Patch("/:id", canWriteNotes, tagsHandler.RenameTag)     // PATCH /api/v1/tags/:id
+	tags.Post("/:id/merge", canWriteNotes, tagsHandler.MergeTag) // POST /api/v1/tags/:id/merge
+	tags.Delete("/:id", canWriteNotes, tagsHandler.DeleteTag)    // DELETE /api/v1/tags/:id
+
+	// Account routes (interactive logins only). Every route registered after this
+	// group rejects personal access tokens.
+	account := protected.Group("", middleware.RequireSession())
+
+	// Authentication routes (protected)
+	account.Post("/auth/logout", authHandler.Logout)
+	account.Post("/auth/resend-verification", authHandler.ResendVerification)
+	account.Post("/auth/2fa/enroll", authHandler.EnrollTwoFactor)
+	account.Post("/auth/2fa/confirm", authHandler.ConfirmTwoFactor)
+	account.Post("/auth/2fa/disable", authHandler.DisableTwoFactor)
+
+	// Device authorization routes (a signed-in user approves a CLI or TV client)
+	account.Get("/device", authHandler.GetDevice)              // GET /api/v1/device?user_code=
+	account.Post("/device/approve", authHandler.ApproveDevice) // POST /api/v1/device/approve
+	account.Post("/device/deny", authHandler.DenyDevice)       // POST /api/v1/device/deny
+
+	// Profile routes (all protected)
+	account.Patch("/profile", authHandler.UpdateProfile)                   // PATCH /api/v1/profile
+	account.Post("/profile/password", authHandler.ChangePassword)          // POST /api/v1/profile/password
+	account.Post("/profile/email", authHandler.RequestEmailChange)         // POST /api/v1/profile/email
+	account.Post("/profile/email/confirm", authHandler.ConfirmEmailChange) // POST /api/v1/profile/email/confirm
+	account.Get("/profile/export", authHandler.ExportAccount)              // GET /api/v1/profile/export
+	account.Delete("/profile", authHandler.DeleteAccount)                  // DELETE /api/v1/profile
+
+	// Session routes (all protected)
+	sessions := account.Group("/sessions")
+	sessions.Get("/", sessionsHandler.GetSessions)            // GET /api/v1/sessions
+	sessions.Delete("/", sessionsHandler.RevokeOtherSessions) // DELETE /api/v1/sessions
+	sessions.Delete("/:id", sessionsHandler.RevokeSession)    // DELETE /api/v1/sessions/:id
+
+	// Workspace routes (all protected)
+	workspaces := account.Group("/workspaces")
+	workspaces.Post("/", workspacesHandler.CreateWorkspace)                                               // POST /api/v1/workspaces
+	workspaces.Get("/", workspacesHandler.GetWorkspaces)                                                  // GET /api/v1/workspaces
+	workspaces.Get("/:workspaceId", workspacesHandler.GetWorkspace)                                       // GET /api/v1/workspaces/:workspaceId
+	workspaces.Patch("/:workspaceId", workspacesHandler.UpdateWorkspace)                                  // PATCH /api/v1/workspaces/:workspaceId
+	workspaces.Delete("/:workspaceId", workspacesHandler.DeleteWorkspace)                                 // DELETE /api/v1/workspaces/:workspaceId
+	workspaces.Get("/:workspaceId/members", workspacesHandler.GetMembers)                                 // GET /api/v1/workspaces/:workspaceId/members
+	workspaces.Post("/:workspaceId/members", workspacesHandler.AddMember)                                 // POST /api/v1/workspaces/:workspaceId/members
+	workspaces.Patch("/:workspaceId/members/:userId", workspacesHandler.UpdateMember)                     // PATCH /api/v1/workspaces/:workspaceId/members/:userId
+	workspaces.Delete("/:workspaceId/members/:userId", workspacesHandler.RemoveMember)                    // DELETE /api/v1/workspaces/:workspaceId/members/:userId
+	workspaces.Post("/:workspaceId/invitations", workspacesHandler.CreateInvitation)                      // POST /api/v1/workspaces/:workspaceId/invitations
+	workspaces.Get("/:workspaceId/invitations", workspacesHandler.GetInvitations)                         // GET /api/v1/workspaces/:workspaceId/invitations
+	workspaces.Delete("/:workspaceId/invitations/:invitationId", workspacesHandler.RevokeInvitation)      // DELETE /api/v1/workspaces/:workspaceId/invitations/:invitationId
+	workspaces.Post("/:workspaceId/invitations/:invitationId/resend", workspacesHandler.ResendInvitation) // POST /api/v1/workspaces/:workspaceId/invitations/:invitationId/resend
+	account.Post("/invitations/accept", workspacesHandler.AcceptInvitation)                               // POST /api/v1/invitations/accept
+
+	// Audit log of the user's own account
+	account.Get("/audit-events", auditHandler.GetMyEvents) // GET /api/v1/audit-events
+
+	// Personal access token routes (all protected)
+	tokens := account.Group("/tokens")
+	tokens.Post("/", tokensHandler.CreateToken)      // POST /api/v1/tokens
+	tokens.Get("/", tokensHandler.GetTokens)         // GET /api/v1/tokens
+	tokens.Delete("/:id", tokensHandler.RevokeToken) // DELETE /api/v1/tokens/:id
+
+	// Admin routes (each route is guarded by a permission)
+	admin := account.Group("/admin")
+	canManageRoles := middleware.RequirePermission(models.PermissionRolesManage)
+	canReadUsers := middleware.RequirePermission(models.PermissionUsersRead)
+	canManageUsers := middleware.RequirePermission(models.PermissionUsersManage)
+	canReadAudit := middleware.RequirePermission(models.PermissionAuditRead)
+	admin.Get("/roles", canManageRoles, rolesHandler.GetRoles)                                    // GET /api/v1/admin/roles
+	admin.Post("/users/:id/roles", canManageRoles, rolesHandler.AssignRole)                       // POST /api/v1/admin/users/:id/roles
+	admin.Delete("/users/:id/roles/:role", canManageRoles, rolesHandler.RemoveRole)               // DELETE /api/v1/admin/users/:id/roles/:role
+	admin.Get("/users", canReadUsers, adminUsersHandler.GetUsers)                                 // GET /api/v1/admin/users
+	admin.Get("/users/:id", canReadUsers, adminUsersHandler.GetUser)                              // GET /api/v1/admin/users/:id
+	admin.Post("/users/:id/disable", canManageUsers, adminUsersHandler.DisableUser)               // POST /api/v1/admin/users/:id/disable
+	admin.Post("/users/:id/enable", canManageUsers, adminUsersHandler.EnableUser)                 // POST /api/v1/admin/users/:id/enable
+	admin.Post("/users/:id/password-reset", canManageUsers, adminUsersHandler.ForcePasswordReset) // POST /api/v1/admin/users/:id/password-reset
+	admin.Delete("/users/:id", canManageUsers, adminUsersHandler.DeleteUser)                      // DELETE /api/v1/admin/users/:id
+	admin.Post("/users/:id/restore", canManageUsers, adminUsersHandler.RestoreUser)               // POST /api/v1/admin/users/:id/restore
+	admin.Get("/audit-events", canReadAudit, auditHandler.GetEvents)                              // GET /api/v1/admin/audit-events
 }
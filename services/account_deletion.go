@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// AccountDeletionGracePeriod returns how long a deletion can be cancelled (default: 14 days)
+func AccountDeletionGracePeriod() time.Duration {
+	return utils.GetEnvDuration("ACCOUNT_DELETION_GRACE_DAYS", 24*time.Hour, 14*24*time.Hour)
+}
+
+// ScheduleAccountDeletion marks the account for deletion after the grace period and signs out everywhere
+func ScheduleAccountDeletion(user *models.User) error {
+	defer Users.Invalidate(user.ID)
+
+	dueAt := time.Now().Add(AccountDeletionGracePeriod())
+	user.DeletionDueAt = &dueAt
+	user.RevokeIssuedTokens()
+
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"deletion_due_at":    user.DeletionDueAt,
+			"tokens_valid_after": user.TokensValidAfter,
+		}).Error; err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, user.ID)
+	})
+}
+
+// CancelAccountDeletion keeps the account when the user signs in during the grace period
+func CancelAccountDeletion(user *models.User) error {
+	if !user.IsDeletionScheduled() {
+		return nil
+	}
+
+	user.DeletionDueAt = nil
+	defer Users.Invalidate(user.ID)
+	return config.GetDB().Model(user).Update("deletion_due_at", nil).Error
+}
+
+// PurgeDueAccounts permanently deletes accounts whose grace period has ended
+func PurgeDueAccounts() (int, error) {
+	var userIDs []uint
+	if err := config.GetDB().Unscoped().Model(&models.User{}).
+		Where("deletion_due_at IS NOT NULL AND deletion_due_at <= ?", time.Now()).
+		Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		if err := purgeUser(userID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunAccountPurger periodically purges accounts due for deletion until the process exits
+func RunAccountPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := PurgeDueAccounts()
+		if err != nil {
+			log.Println("Failed to purge deleted accounts:", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted accounts", purged)
+		}
+	}
+}
+
+// purgeUser hard-deletes a user with their personal notes and every record referencing them.
+// Workspaces the user owns pass to their longest-standing admin, else member; workspaces nobody
+// else uses are deleted. Notes and notebooks the user created in shared workspaces stay there and
+// pass to the workspace owner. Tables with a cascading foreign key (sessions, tokens, identities...)
+// follow the user row. Audit events are kept for the trail but stripped of the user's personal data.
+func purgeUser(userID uint) error {
+	defer Users.Invalidate(userID)
+
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		// The grace period may have been cancelled since the purge started
+		if user.DeletionDueAt == nil || user.DeletionDueAt.After(time.Now()) {
+			return nil
+		}
+
+		// Hand over or delete the workspaces owned by the user
+		var ownedWorkspaceIDs []uint
+		if err := tx.Model(&models.WorkspaceMember{}).
+			Where("user_id = ? AND role = ?", userID, models.WorkspaceRoleOwner).
+			Pluck("workspace_id", &ownedWorkspaceIDs).Error; err != nil {
+			return err
+		}
+		for _, workspaceID := range ownedWorkspaceIDs {
+			transferred, err := transferWorkspaceOwnership(tx, workspaceID, userID)
+			if err != nil {
+				return err
+			}
+			if !transferred {
+				if err := purgeWorkspace(tx, workspaceID); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Content the user created in the remaining shared workspaces passes to their owners
+		if err := reassignWorkspaceContent(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ? AND workspace_id IS NULL", userID).Delete(&models.Note{}).Error; err != nil {
+			return err
+		}
+		if err := deleteAllNotebooks(tx, &NoteScope{UserID: userID}); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RevokedToken{}).Error; err != nil {
+			return err
+		}
+		if err := pseudonymizeAuditEvents(tx, userID, user.Email); err != nil {
+			return err
+		}
+		if err := tx.Where(&models.LoginAttempt{Key: AccountLoginKey(user.Email)}).Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Association("Roles").Clear(); err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&user).Error
+	})
+}
+
+// transferWorkspaceOwnership makes the longest-standing admin, else member, the owner of a workspace
+// the user owns. It reports false when the workspace is deleted or has no other members.
+func transferWorkspaceOwnership(tx *gorm.DB, workspaceID, userID uint) (bool, error) {
+	var workspace models.Workspace
+	if err := tx.Unscoped().First(&workspace, workspaceID).Error; err != nil {
+		return false, err
+	}
+	if workspace.DeletedAt.Valid {
+		return false, nil
+	}
+
+	var successor models.WorkspaceMember
+	err := tx.Where("workspace_id = ? AND user_id <> ?", workspaceID, userID).
+		Order("CASE role WHEN 'admin' THEN 0 ELSE 1 END, created_at ASC, id ASC").
+		First(&successor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Model(&successor).Update("role", models.WorkspaceRoleOwner).Error; err != nil {
+		return false, err
+	}
+	return true, tx.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Update("role", models.WorkspaceRoleMember).Error
+}
+
+// purgeWorkspace hard-deletes a workspace with its notes, notebooks and invitations
+func purgeWorkspace(tx *gorm.DB, workspaceID uint) error {
+	if err := tx.Unscoped().Where("workspace_id = ?", workspaceID).Delete(&models.Note{}).Error; err != nil {
+		return err
+	}
+	if err := deleteAllNotebooks(tx, &NoteScope{Membership: &models.WorkspaceMember{WorkspaceID: workspaceID}}); err != nil {
+		return err
+	}
+	if err := tx.Where("workspace_id = ?", workspaceID).Delete(&models.WorkspaceInvitation{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&models.Workspace{}, workspaceID).Error
+}
+
+// reassignWorkspaceContent passes the notes and notebooks the user created in workspaces to each
+// workspace's owner, so shared content survives its author and no record points at the purged user
+func reassignWorkspaceContent(tx *gorm.DB, userID uint) error {
+	var noteWorkspaceIDs, notebookWorkspaceIDs []uint
+	if err := tx.Unscoped().Model(&models.Note{}).
+		Where("user_id = ? AND workspace_id IS NOT NULL", userID).
+		Distinct().Pluck("workspace_id", &noteWorkspaceIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Notebook{}).
+		Where("user_id = ? AND workspace_id IS NOT NULL", userID).
+		Distinct().Pluck("workspace_id", &notebookWorkspaceIDs).Error; err != nil {
+		return err
+	}
+
+	for _, workspaceID := range append(noteWorkspaceIDs, notebookWorkspaceIDs...) {
+		var owner models.WorkspaceMember
+		if err := tx.Where("workspace_id = ? AND role = ?", workspaceID, models.WorkspaceRoleOwner).
+			First(&owner).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Note{}).
+			Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+			Update("user_id", owner.UserID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Notebook{}).
+			Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+			Update("user_id", owner.UserID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+)
+
+// addTestMember adds the user to the workspace and fails the test on error
+func addTestMember(tb testing.TB, workspaceID uint, user *models.User, role string) *models.WorkspaceMember {
+	tb.Helper()
+
+	member, err := addWorkspaceMember(config.GetDB(), workspaceID, user, role)
+	if err != nil {
+		tb.Fatalf("addWorkspaceMember() error = %v", err)
+	}
+	return member
+}
+
+// createTestNote stores a note of the user in the workspace (nil for the personal workspace)
+func createTestNote(tb testing.TB, userID uint, workspaceID *uint) *models.Note {
+	tb.Helper()
+
+	note := models.Note{Title: "note", Content: "note", UserID: userID, WorkspaceID: workspaceID}
+	if err := config.GetDB().Create(&note).Error; err != nil {
+		tb.Fatalf("create note: %v", err)
+	}
+	return &note
+}
+
+// purgeTestUser schedules the user's deletion in the past and purges due accounts
+func purgeTestUser(tb testing.TB, user *models.User) {
+	tb.Helper()
+
+	if err := config.GetDB().Model(user).Update("deletion_due_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		tb.Fatalf("schedule deletion: %v", err)
+	}
+	if purged, err := PurgeDueAccounts(); err != nil || purged != 1 {
+		tb.Fatalf("PurgeDueAccounts() = %d, %v, want 1 purged account", purged, err)
+	}
+}
+
+func TestPurgeUserTransfersWorkspaces(t *testing.T) {
+	setupTestDB(t)
+	alice := createTestUser(t, "alice@example.com")
+	bob := createTestUser(t, "bob@example.com")
+	carol := createTestUser(t, "carol@example.com")
+
+	// Bob joined first as a member, Carol later as an admin
+	team := createTestWorkspace(t, alice, "Team")
+	addTestMember(t, team.WorkspaceID, bob, models.WorkspaceRoleMember)
+	addTestMember(t, team.WorkspaceID, carol, models.WorkspaceRoleAdmin)
+
+	// Without admins the longest-standing member takes over
+	club := createTestWorkspace(t, alice, "Club")
+	addTestMember(t, club.WorkspaceID, bob, models.WorkspaceRoleMember)
+	addTestMember(t, club.WorkspaceID, carol, models.WorkspaceRoleMember)
+
+	solo := createTestWorkspace(t, alice, "Solo")
+	soloNote := createTestNote(t, alice.ID, &solo.WorkspaceID)
+
+	tests := []struct {
+		name      string
+		workspace *models.WorkspaceMember
+		wantOwner uint
+	}{
+		{name: "workspace with an admin", workspace: team, wantOwner: carol.ID},
+		{name: "workspace with members only", workspace: club, wantOwner: bob.ID},
+	}
+
+	var notes []*models.Note
+	var notebooks []*models.Notebook
+	for _, tt := range tests {
+		notes = append(notes, createTestNote(t, alice.ID, &tt.workspace.WorkspaceID))
+		notebooks = append(notebooks, createTestNotebook(t, &NoteScope{UserID: alice.ID, Membership: tt.workspace}, "shared", nil))
+	}
+
+	purgeTestUser(t, alice)
+	db := config.GetDB()
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var owner models.WorkspaceMember
+			if err := db.Where("workspace_id = ? AND role = ?", tt.workspace.WorkspaceID, models.WorkspaceRoleOwner).First(&owner).Error; err != nil {
+				t.Fatalf("load owner: %v", err)
+			}
+			if owner.UserID != tt.wantOwner {
+				t.Errorf("owner = user %d, want user %d", owner.UserID, tt.wantOwner)
+			}
+
+			var note models.Note
+			if err := db.First(&note, notes[i].ID).Error; err != nil || note.UserID != tt.wantOwner {
+				t.Errorf("note = %+v, %v, want it kept and passed to the new owner", note, err)
+			}
+			var notebook models.Notebook
+			if err := db.First(&notebook, notebooks[i].ID).Error; err != nil || notebook.UserID != tt.wantOwner {
+				t.Errorf("notebook = %+v, %v, want it kept and passed to the new owner", notebook, err)
+			}
+		})
+	}
+
+	var workspaces, soloNotes int64
+	db.Unscoped().Model(&models.Workspace{}).Where("id = ?", solo.WorkspaceID).Count(&workspaces)
+	db.Unscoped().Model(&models.Note{}).Where("id = ?", soloNote.ID).Count(&soloNotes)
+	if workspaces != 0 || soloNotes != 0 {
+		t.Errorf("workspace without other members left %d workspaces and %d notes", workspaces, soloNotes)
+	}
+}
+
+func TestPurgeUserKeepsNotesInOtherWorkspaces(t *testing.T) {
+	setupTestDB(t)
+	alice := createTestUser(t, "alice@example.com")
+	bob := createTestUser(t, "bob@example.com")
+
+	team := createTestWorkspace(t, bob, "Team")
+	membership := addTestMember(t, team.WorkspaceID, alice, models.WorkspaceRoleMember)
+	shared := createTestNote(t, alice.ID, &team.WorkspaceID)
+	notebook := createTestNotebook(t, &NoteScope{UserID: alice.ID, Membership: membership}, "shared", nil)
+	personal := createTestNote(t, alice.ID, nil)
+
+	purgeTestUser(t, alice)
+	db := config.GetDB()
+
+	var note models.Note
+	if err := db.First(&note, shared.ID).Error; err != nil || note.UserID != bob.ID {
+		t.Errorf("shared note = %+v, %v, want it kept and passed to the workspace owner", note, err)
+	}
+	var stored models.Notebook
+	if err := db.First(&stored, notebook.ID).Error; err != nil || stored.UserID != bob.ID {
+		t.Errorf("shared notebook = %+v, %v, want it kept and passed to the workspace owner", stored, err)
+	}
+	var personalNotes int64
+	db.Unscoped().Model(&models.Note{}).Where("id = ?", personal.ID).Count(&personalNotes)
+	if personalNotes != 0 {
+		t.Error("the personal note survived the purge")
+	}
+}
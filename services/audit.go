@@ -0,0 +1,98 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+)
+
+// AuditFilter describes an audit event query. OwnerID restricts results to events
+// belonging to or performed by that user; the other fields are optional filters.
+type AuditFilter struct {
+	OwnerID    *uint
+	ActorID    *uint
+	UserID     *uint
+	Actions    []string
+	TargetType string
+	TargetID   string
+	IPAddress  string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PerPage    int
+}
+
+// RecordAuditEvent appends an event to the audit log
+func RecordAuditEvent(event *models.AuditEvent) error {
+	if len(event.UserAgent) > 255 {
+		event.UserAgent = event.UserAgent[:255]
+	}
+	if event.UserID == nil {
+		event.UserID = event.ActorID
+	}
+	return config.GetDB().Create(event).Error
+}
+
+// pseudonymizeAuditEvents strips the email address, client details and former addresses from
+// a user's audit events. The user and actor IDs are kept so the trail stays consistent.
+func pseudonymizeAuditEvents(tx *gorm.DB, userID uint, email string) error {
+	// Audit events are otherwise immutable, so the hooks are skipped deliberately
+	tx = tx.Session(&gorm.Session{SkipHooks: true})
+
+	if err := tx.Model(&models.AuditEvent{}).
+		Where("(user_id = ? OR actor_id = ?) AND action = ?", userID, userID, models.AuditEmailChanged).
+		Update("details", "").Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.AuditEvent{}).
+		Where("user_id = ? OR actor_id = ? OR email = ?", userID, userID, email).
+		Updates(map[string]interface{}{"email": "", "ip_address": "", "user_agent": ""}).Error
+}
+
+// ListAuditEvents returns a page of audit events matching the filter, newest first, with the total count
+func ListAuditEvents(filter AuditFilter) ([]models.AuditEvent, int64, error) {
+	query := config.GetDB().Model(&models.AuditEvent{})
+
+	if filter.OwnerID != nil {
+		query = query.Where("user_id = ? OR actor_id = ?", *filter.OwnerID, *filter.OwnerID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if targetType := strings.TrimSpace(filter.TargetType); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := strings.TrimSpace(filter.TargetID); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if ip := strings.TrimSpace(filter.IPAddress); ip != "" {
+		query = query.Where("ip_address = ?", ip)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := query.Offset((filter.Page - 1) * filter.PerPage).
+		Limit(filter.PerPage).
+		Order("created_at DESC, id DESC").
+		Find(&events).Error
+	return events, total, err
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"time"
+)
+
+// evictionSample is how many cache entries are inspected to pick one to evict
+const evictionSample = 8
+
+// evictSampled drops the entry expiring first among a few randomly picked ones and returns
+// its key. Map iteration order is random, so this approximates expiry order in constant time
+// instead of scanning the whole map. Callers must hold the cache's write lock.
+func evictSampled[K comparable, V any](entries map[K]V, until func(V) time.Time) K {
+	var victim K
+	var victimUntil time.Time
+	sampled := 0
+	for key, entry := range entries {
+		if sampled == 0 || until(entry).Before(victimUntil) {
+			victim, victimUntil = key, until(entry)
+		}
+		sampled++
+		if sampled == evictionSample {
+			break
+		}
+	}
+	delete(entries, victim)
+	return victim
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrInvalidDeviceCode is returned for unknown or already redeemed device codes
+	ErrInvalidDeviceCode = errors.New("invalid device code")
+	// ErrDeviceAuthorizationPending is returned while the user has not yet approved the device
+	ErrDeviceAuthorizationPending = errors.New("authorization pending")
+	// ErrDeviceSlowDown is returned when the device polls faster than its interval allows
+	ErrDeviceSlowDown = errors.New("polling too fast")
+	// ErrDeviceAccessDenied is returned when the user denied the device
+	ErrDeviceAccessDenied = errors.New("access denied")
+	// ErrDeviceCodeExpired is returned once the device code has expired
+	ErrDeviceCodeExpired = errors.New("device code expired")
+	// ErrUserCodeNotFound is returned for unknown, expired or already answered user codes
+	ErrUserCodeNotFound = errors.New("user code not found")
+)
+
+// userCodeAlphabet avoids vowels and look-alike characters (RFC 8628 section 6.1)
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// deviceSlowDownStep is added to the polling interval each time a device polls too fast
+const deviceSlowDownStep = 5
+
+// DeviceCodeTTL returns how long a device has to be approved (default: 10 minutes)
+func DeviceCodeTTL() time.Duration {
+	return utils.GetEnvDuration("DEVICE_CODE_EXPIRATION_MINUTES", time.Minute, 10*time.Minute)
+}
+
+// DevicePollInterval returns the minimum number of seconds between token requests (default: 5)
+func DevicePollInterval() int {
+	return utils.GetEnvInt("DEVICE_CODE_INTERVAL_SECONDS", 5)
+}
+
+// CreateDeviceAuthorization starts a device authorization and returns it with its raw device code
+func CreateDeviceAuthorization(clientID string) (*models.DeviceAuthorization, string, error) {
+	deviceCode, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, "", err
+	}
+
+	db := config.GetDB()
+
+	// Opportunistically clean up abandoned requests
+	db.Where("expires_at < ?", time.Now()).Delete(&models.DeviceAuthorization{})
+
+	authorization := models.DeviceAuthorization{
+		DeviceCodeHash: utils.HashToken(deviceCode),
+		UserCode:       userCode,
+		ClientID:       strings.TrimSpace(clientID),
+		Interval:       DevicePollInterval(),
+		ExpiresAt:      time.Now().Add(DeviceCodeTTL()),
+	}
+	if err := db.Create(&authorization).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &authorization, deviceCode, nil
+}
+
+// FindPendingDeviceAuthorization looks up a device waiting for approval by its user code
+func FindPendingDeviceAuthorization(userCode string) (*models.DeviceAuthorization, error) {
+	var authorization models.DeviceAuthorization
+	if err := pendingDeviceAuthorizations(userCode).First(&authorization).Error; err != nil {
+		return nil, ErrUserCodeNotFound
+	}
+	return &authorization, nil
+}
+
+// ApproveDeviceAuthorization lets the device sign in as the user
+func ApproveDeviceAuthorization(userCode string, userID uint) (*models.DeviceAuthorization, error) {
+	return answerDeviceAuthorization(userCode, userID, true)
+}
+
+// DenyDeviceAuthorization rejects the device; its next poll receives access_denied
+func DenyDeviceAuthorization(userCode string, userID uint) (*models.DeviceAuthorization, error) {
+	return answerDeviceAuthorization(userCode, userID, false)
+}
+
+// PollDeviceAuthorization checks the authorization behind a device code. Once approved the
+// code is redeemed and the approving user is returned; it cannot be redeemed again.
+func PollDeviceAuthorization(deviceCode, clientID string) (*models.User, *models.DeviceAuthorization, error) {
+	db := config.GetDB()
+
+	var authorization models.DeviceAuthorization
+	if err := db.Where("device_code_hash = ?", utils.HashToken(deviceCode)).First(&authorization).Error; err != nil {
+		return nil, nil, ErrInvalidDeviceCode
+	}
+	if authorization.ClientID != "" && clientID != "" && authorization.ClientID != clientID {
+		return nil, nil, ErrInvalidDeviceCode
+	}
+
+	switch authorization.Status() {
+	case models.DeviceAuthorizationConsumed:
+		return nil, nil, ErrInvalidDeviceCode
+	case models.DeviceAuthorizationExpired:
+		return nil, nil, ErrDeviceCodeExpired
+	}
+
+	// Enforce the polling interval; the condition makes concurrent polls count as too fast as well
+	now := time.Now()
+	result := db.Model(&models.DeviceAuthorization{}).
+		Where("id = ? AND (last_polled_at IS NULL OR last_polled_at <= ?)",
+			authorization.ID, now.Add(-time.Duration(authorization.Interval)*time.Second)).
+		Update("last_polled_at", now)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Make devices that ignore the interval wait longer
+		if err := db.Model(&authorization).Updates(map[string]interface{}{
+			"last_polled_at": now,
+			"interval":       authorization.Interval + deviceSlowDownStep,
+		}).Error; err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrDeviceSlowDown
+	}
+
+	switch authorization.Status() {
+	case models.DeviceAuthorizationDenied:
+		return nil, nil, ErrDeviceAccessDenied
+	case models.DeviceAuthorizationPending:
+		return nil, nil, ErrDeviceAuthorizationPending
+	}
+
+	// Redeem; losing this race means the code was redeemed concurrently
+	result = db.Model(&models.DeviceAuthorization{}).
+		Where("id = ? AND consumed_at IS NULL", authorization.ID).
+		Update("consumed_at", now)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidDeviceCode
+	}
+
+	var user models.User
+	if err := db.First(&user, authorization.UserID).Error; err != nil {
+		return nil, nil, ErrDeviceAccessDenied
+	}
+
+	// The approval is withdrawn when the account was signed out everywhere since (password change,
+	// scheduled deletion, role change) or now needs a password reset before anyone signs in
+	if user.PasswordResetRequired || user.TokenIssuedBeforeWatermark(*authorization.ApprovedAt) {
+		return nil, nil, ErrDeviceAccessDenied
+	}
+
+	return &user, &authorization, nil
+}
+
+// NormalizeUserCode uppercases a user code and restores its dash so codes typed as
+// "bcdf ghjk" or "BCDFGHJK" match the stored "BCDF-GHJK"
+func NormalizeUserCode(userCode string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(userCode) {
+		if strings.ContainsRune(userCodeAlphabet, r) {
+			b.WriteRune(r)
+		}
+	}
+
+	code := b.String()
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// answerDeviceAuthorization records the user's decision on a pending device
+func answerDeviceAuthorization(userCode string, userID uint, approve bool) (*models.DeviceAuthorization, error) {
+	authorization, err := FindPendingDeviceAuthorization(userCode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	column := "denied_at"
+	if approve {
+		column = "approved_at"
+	}
+
+	// Only the first answer counts
+	result := config.GetDB().Model(&models.DeviceAuthorization{}).
+		Where("id = ? AND approved_at IS NULL AND denied_at IS NULL", authorization.ID).
+		Updates(map[string]interface{}{column: now, "user_id": userID})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrUserCodeNotFound
+	}
+
+	if approve {
+		authorization.ApprovedAt = &now
+	} else {
+		authorization.DeniedAt = &now
+	}
+	authorization.UserID = &userID
+	return authorization, nil
+}
+
+// pendingDeviceAuthorizations scopes a query to unanswered, unexpired requests for the user code
+func pendingDeviceAuthorizations(userCode string) *gorm.DB {
+	return config.GetDB().
+		Where("user_code = ? AND approved_at IS NULL AND denied_at IS NULL AND consumed_at IS NULL AND expires_at > ?",
+			NormalizeUserCode(userCode), time.Now())
+}
+
+// generateUserCode returns a random user code formatted as XXXX-XXXX
+func generateUserCode() (string, error) {
+	code := make([]byte, 8)
+	alphabetSize := big.NewInt(int64(len(userCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return NormalizeUserCode(string(code)), nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrDirectoryAccountConflict is returned when a directory login matches a local account that
+	// has its own password or roles the directory manages; such accounts are not linked automatically
+	ErrDirectoryAccountConflict = errors.New("an account with this email address already exists")
+	// ErrDirectoryAccountDeleted is returned when the directory identity or its email address
+	// belongs to a deleted account
+	ErrDirectoryAccountDeleted = errors.New("account has been deleted")
+)
+
+// DirectoryIdentity describes a user authenticated by an external directory such as LDAP
+type DirectoryIdentity struct {
+	Provider string
+	// Subject identifies the user in the directory (for LDAP the entry DN)
+	Subject string
+	Email   string
+	Name    string
+	// Roles are the local roles granted through the user's directory groups
+	Roles []string
+}
+
+// ProvisionDirectoryUser resolves the local user for a directory identity, creating it just in time.
+// Roles listed in managedRoles follow the directory: mapped roles are granted, and roles an earlier
+// login granted are revoked once the user leaves the group. Roles assigned otherwise are left alone.
+func ProvisionDirectoryUser(identity DirectoryIdentity, managedRoles []string) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" {
+		return nil, errors.New("directory entry has no email address")
+	}
+
+	var user models.User
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Previously linked identity; deleted accounts are looked up too so they are not recreated
+		var linked models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&linked).Error
+		switch {
+		case err == nil:
+			if err := tx.Unscoped().First(&user, linked.UserID).Error; err != nil {
+				return err
+			}
+			if user.DeletedAt.Valid {
+				return ErrDirectoryAccountDeleted
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := findOrCreateDirectoryUser(tx, &user, email, identity.Name, managedRoles); err != nil {
+				return err
+			}
+			if err := tx.Create(&models.UserIdentity{
+				UserID:   user.ID,
+				Provider: identity.Provider,
+				Subject:  identity.Subject,
+				Email:    email,
+			}).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return syncDirectoryRoles(tx, &user, identity.Provider, identity.Roles, managedRoles)
+	})
+	if err != nil {
+		return nil, err
+	}
+	Users.Invalidate(user.ID)
+
+	return &user, nil
+}
+
+// findOrCreateDirectoryUser links an account with the same email address or creates a new one.
+// The directory vouches for the address, so it is marked verified. Accounts with a password of
+// their own or with managed roles are refused, as linking them would hand them to the directory.
+// Addresses of deleted accounts are refused with ErrDirectoryAccountDeleted.
+func findOrCreateDirectoryUser(tx *gorm.DB, user *models.User, email, name string, managed []string) error {
+	if err := tx.Unscoped().Where("email = ?", email).First(user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		password, err := utils.GenerateRandomToken(32)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		*user = models.User{
+			Name:            displayName(name, email),
+			Email:           email,
+			Password:        password, // Unusable random password, will be hashed by BeforeCreate hook
+			PasswordUnset:   true,
+			EmailVerifiedAt: &now,
+		}
+		return tx.Create(user).Error
+	}
+
+	if user.DeletedAt.Valid {
+		return ErrDirectoryAccountDeleted
+	}
+	if user.HasLocalPassword() {
+		return ErrDirectoryAccountConflict
+	}
+	if len(managed) > 0 {
+		if count := tx.Model(user).Where("name IN ?", managed).Association("Roles").Count(); count > 0 {
+			return ErrDirectoryAccountConflict
+		}
+	}
+
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(user).Update("email_verified_at", now).Error
+	}
+	return nil
+}
+
+// syncDirectoryRoles grants the roles mapped from directory groups and revokes managed roles the
+// directory granted earlier but no longer maps. Grants are recorded per provider. Like RemoveRole,
+// revoking a role signs out every session so tokens carrying the role stop working.
+func syncDirectoryRoles(tx *gorm.DB, user *models.User, provider string, granted, managed []string) error {
+	if len(managed) == 0 {
+		return nil
+	}
+
+	var roles []models.Role
+	if err := tx.Where("name IN ?", managed).Find(&roles).Error; err != nil {
+		return err
+	}
+	if len(roles) < len(managed) {
+		log.Printf("Directory role mapping refers to unknown roles: %v", managed)
+	}
+
+	grantedSet := make(map[string]bool, len(granted))
+	for _, name := range granted {
+		grantedSet[name] = true
+	}
+
+	// Roles the user holds and the ones the directory granted
+	var held []models.Role
+	if err := tx.Model(user).Association("Roles").Find(&held); err != nil {
+		return err
+	}
+	heldSet := make(map[uint]bool, len(held))
+	for _, role := range held {
+		heldSet[role.ID] = true
+	}
+
+	var grants []models.DirectoryRoleGrant
+	if err := tx.Where("user_id = ? AND provider = ?", user.ID, provider).Find(&grants).Error; err != nil {
+		return err
+	}
+	grantedByDirectory := make(map[uint]bool, len(grants))
+	for _, grant := range grants {
+		grantedByDirectory[grant.RoleID] = true
+	}
+
+	revoked := false
+	for i := range roles {
+		role := &roles[i]
+		switch {
+		case grantedSet[role.Name] && !heldSet[role.ID]:
+			if err := tx.Model(user).Association("Roles").Append(role); err != nil {
+				return err
+			}
+			if !grantedByDirectory[role.ID] {
+				if err := tx.Create(&models.DirectoryRoleGrant{UserID: user.ID, Provider: provider, RoleID: role.ID}).Error; err != nil {
+					return err
+				}
+			}
+		case !grantedSet[role.Name] && grantedByDirectory[role.ID]:
+			if err := tx.Model(user).Association("Roles").Delete(role); err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ? AND provider = ? AND role_id = ?", user.ID, provider, role.ID).
+				Delete(&models.DirectoryRoleGrant{}).Error; err != nil {
+				return err
+			}
+			revoked = true
+		}
+	}
+	if !revoked {
+		return nil
+	}
+
+	user.RevokeIssuedTokens()
+	if err := tx.Model(user).Update("tokens_valid_after", user.TokensValidAfter).Error; err != nil {
+		return err
+	}
+	return revokeUserSessions(tx, user.ID)
+}
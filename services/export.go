@@ -0,0 +1,123 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+)
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// accountExport is the profile data included in an export archive
+type accountExport struct {
+	ExportedAt           time.Time                            `json:"exported_at"`
+	Profile              models.UserResponse                  `json:"profile"`
+	DeletionDueAt        *time.Time                           `json:"deletion_due_at,omitempty"`
+	Sessions             []models.SessionResponse             `json:"sessions"`
+	PersonalAccessTokens []models.PersonalAccessTokenResponse `json:"personal_access_tokens"`
+	LinkedIdentities     []models.UserIdentity                `json:"linked_identities"`
+}
+
+// BuildAccountExport returns a zip archive with the user's profile data and all of their notes.
+// Notes are included both as notes.json and as one Markdown file per note.
+func BuildAccountExport(user *models.User) ([]byte, error) {
+	db := config.GetDB()
+
+	// Collect profile data
+	if err := db.Model(user).Association("Roles").Find(&user.Roles); err != nil {
+		return nil, err
+	}
+
+	var sessions []models.Session
+	if err := db.Where("user_id = ?", user.ID).Order("created_at ASC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	var tokens []models.PersonalAccessToken
+	if err := db.Where("user_id = ?", user.ID).Order("created_at ASC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	var identities []models.UserIdentity
+	if err := db.Where("user_id = ?", user.ID).Order("created_at ASC").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	var notes []models.Note
+	if err := db.Preload("Tags", "tags.user_id = ?", user.ID).Where("user_id = ?", user.ID).Order("created_at ASC").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	export := accountExport{
+		ExportedAt:           time.Now().UTC(),
+		Profile:              user.ToResponse(),
+		DeletionDueAt:        user.DeletionDueAt,
+		Sessions:             make([]models.SessionResponse, 0, len(sessions)),
+		PersonalAccessTokens: make([]models.PersonalAccessTokenResponse, 0, len(tokens)),
+		LinkedIdentities:     identities,
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, session.ToResponse(0))
+	}
+	for _, token := range tokens {
+		export.PersonalAccessTokens = append(export.PersonalAccessTokens, token.ToResponse())
+	}
+
+	noteResponses := make([]models.NoteResponse, 0, len(notes))
+	for _, note := range notes {
+		noteResponses = append(noteResponses, note.ToResponse())
+	}
+
+	// Write archive
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	if err := writeJSONFile(archive, "profile.json", export); err != nil {
+		return nil, err
+	}
+	if err := writeJSONFile(archive, "notes.json", noteResponses); err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		file, err := archive.Create(fmt.Sprintf("notes/%d-%s.md", note.ID, slugify(note.Title)))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(file, "# %s\n\n%s\n", note.Title, note.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSONFile adds an indented JSON document to the archive
+func writeJSONFile(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// slugify turns a note title into a file name friendly string
+func slugify(title string) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		slug = "note"
+	}
+	return slug
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// Key prefixes distinguishing per-account and per-IP counters
+const (
+	loginKeyAccount = "account:"
+	loginKeyIP      = "ip:"
+)
+
+// LoginAttemptState holds the failed login counter of a single key
+type LoginAttemptState struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// LoginAttemptStore persists failed login counters.
+// Update must apply fn atomically so concurrent failures are all counted.
+type LoginAttemptStore interface {
+	Get(key string) (LoginAttemptState, error)
+	Update(key string, fn func(state *LoginAttemptState)) (LoginAttemptState, error)
+	Delete(key string) error
+	PurgeBefore(cutoff time.Time) error
+}
+
+// LoginThrottlePolicy configures backoff and lockout
+type LoginThrottlePolicy struct {
+	// FreeAttempts is the number of failures allowed before backoff starts
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	// Lockout thresholds; the per-IP one is higher because of shared addresses (NAT, proxies)
+	AccountLockoutThreshold int
+	IPLockoutThreshold      int
+	LockoutDuration         time.Duration
+	// Window after which failures are forgotten
+	Window time.Duration
+}
+
+// LoginThrottle slows down and locks out repeated failed logins per account and per client IP
+type LoginThrottle struct {
+	store  LoginAttemptStore
+	policy LoginThrottlePolicy
+}
+
+// LoginFailure describes the outcome of recording a failed attempt for one key
+type LoginFailure struct {
+	Key      string
+	Failures int
+	// Locked is set when this failure started a lockout
+	Locked bool
+}
+
+// NewLoginThrottle creates a throttle with the given store and policy
+func NewLoginThrottle(store LoginAttemptStore, policy LoginThrottlePolicy) *LoginThrottle {
+	return &LoginThrottle{store: store, policy: policy}
+}
+
+// NewLoginThrottleFromEnv creates a throttle configured by the LOGIN_* environment variables.
+// LOGIN_ATTEMPT_STORE selects "memory" (default, single instance) or "database" (shared by all instances).
+func NewLoginThrottleFromEnv() *LoginThrottle {
+	policy := LoginThrottlePolicy{
+		FreeAttempts:            utils.GetEnvInt("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:               utils.GetEnvDuration("LOGIN_BACKOFF_BASE_SECONDS", time.Second, time.Second),
+		MaxDelay:                utils.GetEnvDuration("LOGIN_BACKOFF_MAX_SECONDS", time.Second, 5*time.Minute),
+		AccountLockoutThreshold: utils.GetEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		IPLockoutThreshold:      utils.GetEnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+		LockoutDuration:         utils.GetEnvDuration("LOGIN_LOCKOUT_MINUTES", time.Minute, 15*time.Minute),
+		Window:                  utils.GetEnvDuration("LOGIN_ATTEMPT_WINDOW_MINUTES", time.Minute, 15*time.Minute),
+	}
+
+	var store LoginAttemptStore
+	switch strings.ToLower(utils.GetEnv("LOGIN_ATTEMPT_STORE", "memory")) {
+	case "database", "db":
+		store = NewDatabaseLoginAttemptStore()
+	case "memory":
+		store = NewMemoryLoginAttemptStore()
+	default:
+		log.Println("Unknown LOGIN_ATTEMPT_STORE, falling back to memory")
+		store = NewMemoryLoginAttemptStore()
+	}
+
+	return NewLoginThrottle(store, policy)
+}
+
+// LoginKeys returns the counter keys for a login attempt on the account from the client IP
+func LoginKeys(email, ip string) []string {
+	return []string{AccountLoginKey(email), loginKeyIP + ip}
+}
+
+// AccountLoginKey returns the per-account counter key
+func AccountLoginKey(email string) string {
+	return loginKeyAccount + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IsIPLoginKey reports whether the key counts failures of a client IP
+func IsIPLoginKey(key string) bool {
+	return strings.HasPrefix(key, loginKeyIP)
+}
+
+// RetryAfter returns how long the caller has to wait before the next attempt is evaluated
+func (t *LoginThrottle) RetryAfter(keys ...string) (time.Duration, error) {
+	now := time.Now()
+
+	var wait time.Duration
+	for _, key := range keys {
+		state, err := t.store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if d := t.nextAttemptAt(state).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed attempt against every key
+func (t *LoginThrottle) RecordFailure(keys ...string) ([]LoginFailure, error) {
+	now := time.Now()
+
+	failures := make([]LoginFailure, 0, len(keys))
+	for _, key := range keys {
+		threshold := t.lockoutThreshold(key)
+
+		locked := false
+		state, err := t.store.Update(key, func(state *LoginAttemptState) {
+			if now.Sub(state.LastFailureAt) > t.policy.Window && now.After(state.LockedUntil) {
+				state.Failures = 0
+			}
+			state.Failures++
+			state.LastFailureAt = now
+
+			if threshold > 0 && state.Failures >= threshold && now.After(state.LockedUntil) {
+				state.LockedUntil = now.Add(t.policy.LockoutDuration)
+				locked = true
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		failures = append(failures, LoginFailure{Key: key, Failures: state.Failures, Locked: locked})
+	}
+	return failures, nil
+}
+
+// Reset forgets the failures of a key after a successful login
+func (t *LoginThrottle) Reset(key string) error {
+	return t.store.Delete(key)
+}
+
+// RunJanitor periodically removes stale counters until the process exits
+func (t *LoginThrottle) RunJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		retention := t.policy.Window
+		if t.policy.LockoutDuration > retention {
+			retention = t.policy.LockoutDuration
+		}
+		if err := t.store.PurgeBefore(time.Now().Add(-retention)); err != nil {
+			log.Println("Failed to purge login attempts:", err)
+		}
+	}
+}
+
+// nextAttemptAt returns when the next attempt is allowed given the exponential backoff and any lockout
+func (t *LoginThrottle) nextAttemptAt(state LoginAttemptState) time.Time {
+	next := state.LockedUntil
+
+	excess := state.Failures - t.policy.FreeAttempts
+	if excess > 0 && time.Since(state.LastFailureAt) <= t.policy.Window {
+		delay := t.policy.MaxDelay
+		if excess < 32 {
+			if d := t.policy.BaseDelay << (excess - 1); d > 0 && d < delay {
+				delay = d
+			}
+		}
+		if backoff := state.LastFailureAt.Add(delay); backoff.After(next) {
+			next = backoff
+		}
+	}
+
+	return next
+}
+
+// lockoutThreshold returns the number of failures that locks the key
+func (t *LoginThrottle) lockoutThreshold(key string) int {
+	if IsIPLoginKey(key) {
+		return t.policy.IPLockoutThreshold
+	}
+	return t.policy.AccountLockoutThreshold
+}
+
+// MemoryLoginAttemptStore keeps counters in process memory (single instance deployments)
+type MemoryLoginAttemptStore struct {
+	mu     sync.Mutex
+	states map[string]LoginAttemptState
+}
+
+// NewMemoryLoginAttemptStore creates an empty in-memory store
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{states: make(map[string]LoginAttemptState)}
+}
+
+// Get implements LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Get(key string) (LoginAttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+// Update implements LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Update(key string, fn func(state *LoginAttemptState)) (LoginAttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.states[key]
+	fn(&state)
+	s.states[key] = state
+	return state, nil
+}
+
+// Delete implements LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.states, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// PurgeBefore implements LoginAttemptStore
+func (s *MemoryLoginAttemptStore) PurgeBefore(cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, state := range s.states {
+		if state.LastFailureAt.Before(cutoff) && state.LockedUntil.Before(cutoff) {
+			delete(s.states, key)
+		}
+	}
+	return nil
+}
+
+// DatabaseLoginAttemptStore keeps counters in the login_attempts table so all instances share them
+type DatabaseLoginAttemptStore struct{}
+
+// NewDatabaseLoginAttemptStore creates a database backed store
+func NewDatabaseLoginAttemptStore() *DatabaseLoginAttemptStore {
+	return &DatabaseLoginAttemptStore{}
+}
+
+// Get implements LoginAttemptStore
+func (s *DatabaseLoginAttemptStore) Get(key string) (LoginAttemptState, error) {
+	var attempt models.LoginAttempt
+	result := config.GetDB().Where(&models.LoginAttempt{Key: key}).Limit(1).Find(&attempt)
+	if result.Error != nil {
+		return LoginAttemptState{}, result.Error
+	}
+	return attemptState(attempt), nil
+}
+
+// Update implements LoginAttemptStore; the row is locked for the duration of the update
+func (s *DatabaseLoginAttemptStore) Update(key string, fn func(state *LoginAttemptState)) (LoginAttemptState, error) {
+	var state LoginAttemptState
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Make sure the row exists so it can be locked
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginAttempt{Key: key}).Error; err != nil {
+			return err
+		}
+
+		var attempt models.LoginAttempt
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&models.LoginAttempt{Key: key}).First(&attempt).Error; err != nil {
+			return err
+		}
+
+		state = attemptState(attempt)
+		fn(&state)
+
+		return tx.Model(&attempt).Updates(map[string]interface{}{
+			"failures":        state.Failures,
+			"last_failure_at": optionalTime(state.LastFailureAt),
+			"locked_until":    optionalTime(state.LockedUntil),
+		}).Error
+	})
+	return state, err
+}
+
+// Delete implements LoginAttemptStore
+func (s *DatabaseLoginAttemptStore) Delete(key string) error {
+	return config.GetDB().Where(&models.LoginAttempt{Key: key}).Delete(&models.LoginAttempt{}).Error
+}
+
+// PurgeBefore implements LoginAttemptStore
+func (s *DatabaseLoginAttemptStore) PurgeBefore(cutoff time.Time) error {
+	return config.GetDB().
+		Where("(last_failure_at IS NULL OR last_failure_at < ?) AND (locked_until IS NULL OR locked_until < ?)", cutoff, cutoff).
+		Delete(&models.LoginAttempt{}).Error
+}
+
+// attemptState converts a stored row into its state; missing times become zero times
+func attemptState(attempt models.LoginAttempt) LoginAttemptState {
+	state := LoginAttemptState{Failures: attempt.Failures}
+	if attempt.LastFailureAt != nil {
+		state.LastFailureAt = *attempt.LastFailureAt
+	}
+	if attempt.LockedUntil != nil {
+		state.LockedUntil = *attempt.LockedUntil
+	}
+	return state
+}
+
+// optionalTime stores zero times as NULL, which strict MySQL modes require instead of a zero datetime
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// ErrMagicLinkWrongBrowser is returned when a sign-in link is redeemed without the nonce of the browser that requested it
+var ErrMagicLinkWrongBrowser = errors.New("sign-in link was requested from another browser")
+
+// MagicLinkTTL returns how long passwordless sign-in links stay valid (default: 15 minutes)
+func MagicLinkTTL() time.Duration {
+	return utils.GetEnvDuration("MAGIC_LINK_EXPIRATION_MINUTES", time.Minute, 15*time.Minute)
+}
+
+// CreateMagicLink issues a sign-in token for the user that only works together with the browser nonce.
+// Only the hash of the nonce is stored.
+func CreateMagicLink(userID uint, nonce string) (string, error) {
+	return CreateOneTimeToken(userID, models.TokenPurposeMagicLink, MagicLinkTTL(), utils.HashToken(nonce))
+}
+
+// RedeemMagicLink consumes a sign-in token presented with the nonce of the requesting browser
+// and returns the user to sign in. Following the link also verifies the user's email address.
+func RedeemMagicLink(rawToken, nonce string) (*models.User, error) {
+	db := config.GetDB()
+
+	var token models.OneTimeToken
+	if err := db.Where("token_hash = ? AND purpose = ?", utils.HashToken(rawToken), models.TokenPurposeMagicLink).
+		First(&token).Error; err != nil {
+		return nil, ErrInvalidOneTimeToken
+	}
+
+	// Check the browser before consuming so opening the link elsewhere does not burn it
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(token.Payload), []byte(utils.HashToken(nonce))) != 1 {
+		return nil, ErrMagicLinkWrongBrowser
+	}
+	if _, err := ConsumeOneTimeToken(models.TokenPurposeMagicLink, rawToken); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := db.First(&user, token.UserID).Error; err != nil {
+		return nil, ErrInvalidOneTimeToken
+	}
+
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := db.Model(&user).Update("email_verified_at", now).Error; err != nil {
+			return nil, err
+		}
+		Users.Invalidate(user.ID)
+	}
+
+	return &user, nil
+}
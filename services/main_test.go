@@ -0,0 +1,56 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"notes-api/config"
+	"notes-api/models"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+// setupTestDB points config.DB at a fresh in-memory database and resets the user cache
+func setupTestDB(tb testing.TB) {
+	tb.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(tb.Name())
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared&_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		tb.Fatalf("open database: %v", err)
+	}
+
+	config.DB = db
+	if err := config.Migrate(); err != nil {
+		tb.Fatalf("migrate database: %v", err)
+	}
+	Users = NewUserCache()
+
+	tb.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// createTestUser stores a verified user; the password is not hashed
+func createTestUser(tb testing.TB, email string) *models.User {
+	tb.Helper()
+
+	now := time.Now()
+	user := models.User{Name: "Test User", Email: email, Password: "unused", EmailVerifiedAt: &now}
+	if err := config.GetDB().Create(&user).Error; err != nil {
+		tb.Fatalf("create user: %v", err)
+	}
+	return &user
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"notes-api/config"
+	"notes-api/models"
+)
+
+var (
+	// ErrNotebookNotFound is returned when a notebook does not exist in the scope's workspace
+	ErrNotebookNotFound = errors.New("notebook not found")
+	// ErrNotebookCycle is returned when moving a notebook into itself or one of its descendants
+	ErrNotebookCycle = errors.New("a notebook cannot be moved into itself or one of its descendants")
+)
+
+// Notebooks returns a query over the notebooks in the scope
+func (s *NoteScope) Notebooks() *gorm.DB {
+	return s.notebooks(config.GetDB())
+}
+
+// FindNotebook loads a notebook in the scope
+func (s *NoteScope) FindNotebook(notebookID uint) (*models.Notebook, error) {
+	var notebook models.Notebook
+	if err := s.Notebooks().Where("notebooks.id = ?", notebookID).First(&notebook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotebookNotFound
+		}
+		return nil, err
+	}
+	return &notebook, nil
+}
+
+// CanModifyNotebook reports whether the user may rename, move or delete the notebook (same rule as CanModify)
+func (s *NoteScope) CanModifyNotebook(notebook *models.Notebook) bool {
+	return s.canModifyCreatedBy(notebook.UserID)
+}
+
+// FilterByNotebook restricts a notes query to notes directly in the notebook or, with recursive, anywhere in its subtree
+func (s *NoteScope) FilterByNotebook(query *gorm.DB, notebookID uint, recursive bool) (*gorm.DB, error) {
+	if !recursive {
+		return query.Where("notes.notebook_id = ?", notebookID), nil
+	}
+
+	tree, err := loadNotebookTree(config.GetDB(), s)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where("notes.notebook_id IN ?", tree.subtree(notebookID)), nil
+}
+
+// ListNotebooks returns the scope's top-level notebooks with their nested notebooks and note counts
+func ListNotebooks(scope *NoteScope) ([]models.NotebookResponse, error) {
+	tree, err := loadNotebookTree(config.GetDB(), scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.loadNoteCounts(scope); err != nil {
+		return nil, err
+	}
+
+	roots := make([]models.NotebookResponse, 0, len(tree.children[0]))
+	for _, id := range tree.children[0] {
+		roots = append(roots, tree.response(id))
+	}
+	return roots, nil
+}
+
+// GetNotebookTree returns the notebook with its nested notebooks and note counts
+func GetNotebookTree(scope *NoteScope, notebook *models.Notebook) (*models.NotebookResponse, error) {
+	tree, err := loadNotebookTree(config.GetDB(), scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.loadNoteCounts(scope); err != nil {
+		return nil, err
+	}
+
+	if _, ok := tree.notebooks[notebook.ID]; !ok {
+		return nil, ErrNotebookNotFound
+	}
+
+	response := tree.response(notebook.ID)
+	return &response, nil
+}
+
+// CreateNotebook creates a notebook in the scope, at the top level or inside parentID
+func CreateNotebook(scope *NoteScope, name string, parentID *uint) (*models.Notebook, error) {
+	if parentID != nil {
+		if _, err := scope.FindNotebook(*parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	notebook := models.Notebook{
+		Name:        strings.TrimSpace(name),
+		ParentID:    parentID,
+		UserID:      scope.UserID,
+		WorkspaceID: scope.WorkspaceID(),
+	}
+	if err := config.GetDB().Create(&notebook).Error; err != nil {
+		return nil, err
+	}
+	return &notebook, nil
+}
+
+// RenameNotebook renames a notebook
+func RenameNotebook(notebook *models.Notebook, name string) error {
+	notebook.Name = strings.TrimSpace(name)
+	return config.GetDB().Model(notebook).Update("name", notebook.Name).Error
+}
+
+// MoveNotebook moves a notebook with its whole subtree below parentID, or to the top level when
+// parentID is nil. Moves that would make a notebook its own ancestor are refused.
+func MoveNotebook(scope *NoteScope, notebook *models.Notebook, parentID *uint) error {
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Lock the scope's notebooks so concurrent moves cannot combine into a cycle
+		tree, err := loadNotebookTree(tx.Clauses(clause.Locking{Strength: "UPDATE"}), scope)
+		if err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			if _, ok := tree.notebooks[*parentID]; !ok {
+				return ErrNotebookNotFound
+			}
+			for _, id := range tree.subtree(notebook.ID) {
+				if id == *parentID {
+					return ErrNotebookCycle
+				}
+			}
+		}
+
+		notebook.ParentID = parentID
+		return tx.Model(notebook).Update("parent_id", parentID).Error
+	})
+}
+
+// DeleteNotebook deletes a notebook and its nested notebooks. With deleteNotes the notes in the
+// subtree are deleted too; otherwise nested notebooks and notes move up to the notebook's parent.
+// It returns the number of notes deleted or moved.
+func DeleteNotebook(scope *NoteScope, notebook *models.Notebook, deleteNotes bool) (int64, error) {
+	var affected int64
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if !deleteNotes {
+			if err := tx.Model(&models.Notebook{}).Where("parent_id = ?", notebook.ID).Update("parent_id", notebook.ParentID).Error; err != nil {
+				return err
+			}
+			result := tx.Model(&models.Note{}).Where("notebook_id = ?", notebook.ID).Update("notebook_id", notebook.ParentID)
+			if result.Error != nil {
+				return result.Error
+			}
+			affected = result.RowsAffected
+			return tx.Delete(notebook).Error
+		}
+
+		tree, err := loadNotebookTree(tx.Clauses(clause.Locking{Strength: "UPDATE"}), scope)
+		if err != nil {
+			return err
+		}
+		ids := tree.subtree(notebook.ID)
+
+		// Members may only delete what they created themselves
+		if scope.Membership != nil && !scope.Membership.CanManageMembers() {
+			for _, id := range ids {
+				if tree.notebooks[id].UserID != scope.UserID {
+					return ErrWorkspaceForbidden
+				}
+			}
+			var foreignNotes int64
+			if err := tx.Model(&models.Note{}).Where("notebook_id IN ? AND user_id <> ?", ids, scope.UserID).Count(&foreignNotes).Error; err != nil {
+				return err
+			}
+			if foreignNotes > 0 {
+				return ErrWorkspaceForbidden
+			}
+		}
+
+		result := tx.Where("notebook_id IN ?", ids).Delete(&models.Note{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+
+		// Delete the deepest notebooks first so no delete has to cascade through many levels
+		for i := len(ids) - 1; i >= 0; i-- {
+			if err := tx.Delete(&models.Notebook{}, ids[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return affected, err
+}
+
+// deleteAllNotebooks deletes every notebook in the scope, the deepest first so no delete has to
+// cascade through many levels
+func deleteAllNotebooks(tx *gorm.DB, scope *NoteScope) error {
+	tree, err := loadNotebookTree(tx, scope)
+	if err != nil {
+		return err
+	}
+
+	// The subtree of the top level lists parents before their children
+	ids := tree.subtree(0)[1:]
+	for i := len(ids) - 1; i >= 0; i-- {
+		if err := tx.Delete(&models.Notebook{}, ids[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveNote files a note in a notebook of its workspace, or takes it out of all notebooks when notebookID is nil
+func MoveNote(scope *NoteScope, note *models.Note, notebookID *uint) error {
+	if notebookID != nil {
+		if _, err := scope.FindNotebook(*notebookID); err != nil {
+			return err
+		}
+	}
+
+	note.NotebookID = notebookID
+	return config.GetDB().Model(note).Update("notebook_id", notebookID).Error
+}
+
+// notebooks returns a query over the notebooks in the scope using db
+func (s *NoteScope) notebooks(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.Notebook{})
+	if s.Membership == nil {
+		return query.Where("notebooks.user_id = ? AND notebooks.workspace_id IS NULL", s.UserID)
+	}
+	return query.Where("notebooks.workspace_id = ?", s.Membership.WorkspaceID)
+}
+
+// notebookTree indexes the notebooks of a scope by parent
+type notebookTree struct {
+	notebooks map[uint]*models.Notebook
+	// children maps a notebook ID (0 for the top level) to its child notebooks sorted by name
+	children   map[uint][]uint
+	noteCounts map[uint]int64
+}
+
+// loadNotebookTree loads every notebook in the scope
+func loadNotebookTree(db *gorm.DB, scope *NoteScope) (*notebookTree, error) {
+	var notebooks []models.Notebook
+	if err := scope.notebooks(db).Order("notebooks.name ASC, notebooks.id ASC").Find(&notebooks).Error; err != nil {
+		return nil, err
+	}
+
+	tree := &notebookTree{
+		notebooks: make(map[uint]*models.Notebook, len(notebooks)),
+		children:  make(map[uint][]uint),
+	}
+	for i := range notebooks {
+		notebook := &notebooks[i]
+		tree.notebooks[notebook.ID] = notebook
+
+		var parentID uint
+		if notebook.ParentID != nil {
+			parentID = *notebook.ParentID
+		}
+		tree.children[parentID] = append(tree.children[parentID], notebook.ID)
+	}
+	return tree, nil
+}
+
+// loadNoteCounts counts the notes filed directly in each notebook
+func (t *notebookTree) loadNoteCounts(scope *NoteScope) error {
+	var counts []struct {
+		NotebookID uint
+		Count      int64
+	}
+	if err := scope.Notes().
+		Select("notes.notebook_id, COUNT(*) AS count").
+		Where("notes.notebook_id IS NOT NULL").
+		Group("notes.notebook_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	t.noteCounts = make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		t.noteCounts[count.NotebookID] = count.Count
+	}
+	return nil
+}
+
+// subtree returns the notebook's ID followed by its descendants, parents always before their children
+func (t *notebookTree) subtree(notebookID uint) []uint {
+	ids := []uint{notebookID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, t.children[ids[i]]...)
+	}
+	return ids
+}
+
+// response converts a notebook and its descendants to a NotebookResponse
+func (t *notebookTree) response(notebookID uint) models.NotebookResponse {
+	response := t.notebooks[notebookID].ToResponse(t.noteCounts[notebookID])
+	for _, childID := range t.children[notebookID] {
+		response.Children = append(response.Children, t.response(childID))
+	}
+	return response
+}
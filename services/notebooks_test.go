@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+)
+
+// createTestNotebook creates a notebook in the scope and fails the test on error
+func createTestNotebook(tb testing.TB, scope *NoteScope, name string, parentID *uint) *models.Notebook {
+	tb.Helper()
+
+	notebook, err := CreateNotebook(scope, name, parentID)
+	if err != nil {
+		tb.Fatalf("CreateNotebook(%q) error = %v", name, err)
+	}
+	return notebook
+}
+
+func TestMoveNotebook(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "alice@example.com")
+	other := createTestUser(t, "bob@example.com")
+	scope := &NoteScope{UserID: user.ID}
+
+	// work > projects > archive, and a separate personal notebook
+	work := createTestNotebook(t, scope, "work", nil)
+	projects := createTestNotebook(t, scope, "projects", &work.ID)
+	archive := createTestNotebook(t, scope, "archive", &projects.ID)
+	personal := createTestNotebook(t, scope, "personal", nil)
+	foreign := createTestNotebook(t, &NoteScope{UserID: other.ID}, "foreign", nil)
+	missing := uint(9999)
+
+	tests := []struct {
+		name     string
+		notebook *models.Notebook
+		parentID *uint
+		wantErr  error
+	}{
+		{name: "into itself", notebook: work, parentID: &work.ID, wantErr: ErrNotebookCycle},
+		{name: "into its child", notebook: work, parentID: &projects.ID, wantErr: ErrNotebookCycle},
+		{name: "into its grandchild", notebook: work, parentID: &archive.ID, wantErr: ErrNotebookCycle},
+		{name: "into a notebook of another user", notebook: work, parentID: &foreign.ID, wantErr: ErrNotebookNotFound},
+		{name: "into a missing notebook", notebook: work, parentID: &missing, wantErr: ErrNotebookNotFound},
+		{name: "into another tree", notebook: work, parentID: &personal.ID},
+		{name: "grandchild to the top level", notebook: archive, parentID: nil},
+		{name: "former ancestor under its former descendant", notebook: projects, parentID: &archive.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.notebook.ParentID
+
+			err := MoveNotebook(scope, tt.notebook, tt.parentID)
+			if err != tt.wantErr {
+				t.Fatalf("MoveNotebook() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var stored models.Notebook
+			if err := config.GetDB().First(&stored, tt.notebook.ID).Error; err != nil {
+				t.Fatalf("load notebook: %v", err)
+			}
+			want := tt.parentID
+			if tt.wantErr != nil {
+				want = before
+			}
+			if (stored.ParentID == nil) != (want == nil) || (want != nil && *stored.ParentID != *want) {
+				t.Errorf("parent = %v, want %v", stored.ParentID, want)
+			}
+		})
+	}
+}
+
+func TestPurgeUserDeletesNestedNotebooks(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "alice@example.com")
+	scope := &NoteScope{UserID: user.ID}
+
+	// A deep chain and a wide level of notebooks
+	var parentID *uint
+	for i := 0; i < 50; i++ {
+		notebook := createTestNotebook(t, scope, "level", parentID)
+		parentID = &notebook.ID
+	}
+	for i := 0; i < 10; i++ {
+		createTestNotebook(t, scope, "sibling", parentID)
+	}
+
+	if err := config.GetDB().Model(user).Update("deletion_due_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("schedule deletion: %v", err)
+	}
+	purged, err := PurgeDueAccounts()
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDueAccounts() = %d, %v, want 1 purged account", purged, err)
+	}
+
+	var notebooks int64
+	config.GetDB().Model(&models.Notebook{}).Where("user_id = ?", user.ID).Count(&notebooks)
+	if notebooks != 0 {
+		t.Errorf("%d notebooks left after the purge", notebooks)
+	}
+}
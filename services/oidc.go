@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/oidc"
+	"notes-api/utils"
+)
+
+// oidcLoginStateTTL bounds how long a user may spend at the identity provider
+const oidcLoginStateTTL = 10 * time.Minute
+
+var (
+	// ErrInvalidOIDCState is returned for unknown, expired or mismatched login states
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	// ErrOIDCEmailNotVerified is returned when the provider does not vouch for the email address
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not verify the email address")
+	// ErrOIDCAccountDeleted is returned when the identity or its email address belongs to a deleted account
+	ErrOIDCAccountDeleted = errors.New("account has been deleted")
+	// ErrOIDCAccountConflict is returned when the email address belongs to an unverified account with its
+	// own password; linking it would hand the account to whoever registered the address first
+	ErrOIDCAccountConflict = errors.New("an unverified account with this email address already exists")
+)
+
+// CreateOIDCLoginState stores a new login attempt and returns its state, nonce and PKCE verifier
+func CreateOIDCLoginState(provider string) (string, string, string, error) {
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	verifier, err := utils.GenerateRandomToken(48)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	db := config.GetDB()
+
+	// Opportunistically clean up abandoned logins
+	db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+
+	if err := db.Create(&models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	}).Error; err != nil {
+		return "", "", "", err
+	}
+
+	return state, nonce, verifier, nil
+}
+
+// ConsumeOIDCLoginState looks up and deletes a login attempt so its state cannot be reused
+func ConsumeOIDCLoginState(provider, state string) (*models.OIDCLoginState, error) {
+	db := config.GetDB()
+
+	var loginState models.OIDCLoginState
+	if err := db.Where("state_hash = ? AND provider = ?", utils.HashToken(state), provider).First(&loginState).Error; err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	result := db.Delete(&loginState)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	return &loginState, nil
+}
+
+// FindOrCreateOIDCUser resolves the local user for a validated ID token.
+// Known identities are used directly; otherwise the user is linked or created by verified email.
+// Identities and addresses of soft-deleted accounts are refused with ErrOIDCAccountDeleted.
+func FindOrCreateOIDCUser(provider string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	db := config.GetDB()
+
+	// Previously linked identity; deleted accounts are looked up too so they are not recreated
+	var identity models.UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error; err == nil {
+		var user models.User
+		if err := db.Unscoped().First(&user, identity.UserID).Error; err != nil {
+			return nil, err
+		}
+		if user.DeletedAt.Valid {
+			return nil, ErrOIDCAccountDeleted
+		}
+		return &user, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !bool(claims.EmailVerified) {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	var user models.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Link to an existing account with the same verified address or create a new one
+		if err := tx.Unscoped().Where("email = ?", email).First(&user).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			password, err := utils.GenerateRandomToken(32)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			user = models.User{
+				Name:            displayName(claims.Name, email),
+				Email:           email,
+				Password:        password, // Unusable random password, will be hashed by BeforeCreate hook
+				PasswordUnset:   true,
+				EmailVerifiedAt: &now,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		} else if user.DeletedAt.Valid {
+			return ErrOIDCAccountDeleted
+		} else if !user.IsEmailVerified() && user.HasLocalPassword() {
+			return ErrOIDCAccountConflict
+		} else if err := linkExistingOIDCUser(tx, &user); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    email,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	Users.Invalidate(user.ID)
+
+	return &user, nil
+}
+
+// linkExistingOIDCUser hands an existing account over to the identity provider: the provider
+// vouches for the address, the local password is replaced by an unusable one and every session
+// is signed out, so nobody who knew the old password keeps access.
+func linkExistingOIDCUser(tx *gorm.DB, user *models.User) error {
+	password, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	user.PasswordUnset = true
+
+	updates := map[string]interface{}{
+		"password":           user.Password,
+		"password_unset":     true,
+		"tokens_valid_after": user.TokensValidAfter,
+	}
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		updates["email_verified_at"] = now
+	}
+	if err := tx.Model(user).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	return revokeUserSessions(tx, user.ID)
+}
+
+// displayName picks a usable name for users created from an external identity
+func displayName(name, email string) string {
+	name = strings.TrimSpace(name)
+	if len(name) < 2 {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return name
+}
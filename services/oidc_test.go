@@ -0,0 +1,121 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/oidc"
+)
+
+func TestFindOrCreateOIDCUserExistingAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		verified      bool
+		passwordUnset bool
+		deleted       bool
+		wantErr       error
+	}{
+		{name: "verified account with its own password", verified: true},
+		{name: "verified account from another identity provider", verified: true, passwordUnset: true},
+		{name: "unverified account without a password", passwordUnset: true},
+		{name: "unverified account with its own password", wantErr: ErrOIDCAccountConflict},
+		{name: "deleted account", verified: true, deleted: true, wantErr: ErrOIDCAccountDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			db := config.GetDB()
+
+			existing := createTestUser(t, "alice@example.com")
+			updates := map[string]interface{}{"password_unset": tt.passwordUnset}
+			if !tt.verified {
+				updates["email_verified_at"] = nil
+			}
+			if err := db.Model(existing).Updates(updates).Error; err != nil {
+				t.Fatalf("update user: %v", err)
+			}
+			pair, err := IssueTokenPair(existing, SessionMeta{})
+			if err != nil {
+				t.Fatalf("IssueTokenPair() error = %v", err)
+			}
+			if tt.deleted {
+				db.Delete(existing)
+			}
+
+			user, err := FindOrCreateOIDCUser("test", &oidc.IDTokenClaims{
+				Email:            "Alice@Example.com",
+				EmailVerified:    true,
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "alice"},
+			})
+			if err != tt.wantErr {
+				t.Fatalf("FindOrCreateOIDCUser() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var identities int64
+			db.Model(&models.UserIdentity{}).Where("user_id = ?", existing.ID).Count(&identities)
+			var stored models.User
+			if err := db.Unscoped().First(&stored, existing.ID).Error; err != nil {
+				t.Fatalf("load user: %v", err)
+			}
+			if tt.wantErr != nil {
+				if identities != 0 || !stored.CheckPassword("unused") {
+					t.Error("a refused account was linked to the identity provider")
+				}
+				return
+			}
+
+			if user.ID != existing.ID || identities != 1 {
+				t.Fatalf("FindOrCreateOIDCUser() user = %d with %d identities, want the existing user linked once", user.ID, identities)
+			}
+			if !stored.IsEmailVerified() || stored.HasLocalPassword() || stored.CheckPassword("unused") {
+				t.Errorf("linked user = %+v, want a verified account without a usable password", stored)
+			}
+			if _, err := GetActiveSession(existing.ID, pair.SessionID); err == nil {
+				t.Error("a session from before the link survived")
+			}
+			if cached, err := Users.Get(existing.ID); err != nil || !cached.IsEmailVerified() || cached.HasLocalPassword() {
+				t.Errorf("cached user = %+v, %v, want the linked account", cached, err)
+			}
+		})
+	}
+}
+
+func TestFindOrCreateOIDCUserNewAccount(t *testing.T) {
+	setupTestDB(t)
+
+	claims := &oidc.IDTokenClaims{
+		Email:            "bob@example.com",
+		EmailVerified:    true,
+		Name:             "Bob",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "bob"},
+	}
+	created, err := FindOrCreateOIDCUser("test", claims)
+	if err != nil {
+		t.Fatalf("FindOrCreateOIDCUser() error = %v", err)
+	}
+	if created.Name != "Bob" || !created.IsEmailVerified() || created.HasLocalPassword() {
+		t.Errorf("created user = %+v", created)
+	}
+
+	// The identity is found again even after the provider changes the address
+	claims.Email = "robert@example.com"
+	found, err := FindOrCreateOIDCUser("test", claims)
+	if err != nil || found.ID != created.ID {
+		t.Fatalf("FindOrCreateOIDCUser() = %v, %v, want user %d", found, err, created.ID)
+	}
+
+	// Unverified addresses are not trusted for new identities
+	claims.Subject, claims.EmailVerified = "carol", false
+	if _, err := FindOrCreateOIDCUser("test", claims); err != ErrOIDCEmailNotVerified {
+		t.Errorf("FindOrCreateOIDCUser() error = %v, want ErrOIDCEmailNotVerified", err)
+	}
+
+	var users int64
+	config.GetDB().Model(&models.User{}).Count(&users)
+	if users != 1 {
+		t.Errorf("%d users exist, want 1", users)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// ErrInvalidOneTimeToken is returned for unknown, expired or already used one-time tokens
+var ErrInvalidOneTimeToken = errors.New("invalid or expired token")
+
+// CreateOneTimeToken issues a single-use token for the user and returns its raw value.
+// Earlier unused tokens with the same purpose are invalidated.
+func CreateOneTimeToken(userID uint, purpose string, ttl time.Duration, payload string) (string, error) {
+	rawToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OneTimeToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.OneTimeToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(rawToken),
+			Payload:   payload,
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// ConsumeOneTimeToken redeems a token for the given purpose so it cannot be used again
+func ConsumeOneTimeToken(purpose, rawToken string) (*models.OneTimeToken, error) {
+	token, err := FindOneTimeToken(purpose, rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := markOneTimeTokenUsed(config.GetDB(), token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindOneTimeToken looks up an unused, unexpired token without redeeming it.
+// Callers redeem it with the change it authorizes, so a rejected request keeps the token valid.
+func FindOneTimeToken(purpose, rawToken string) (*models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	if err := config.GetDB().Where("token_hash = ? AND purpose = ?", utils.HashToken(rawToken), purpose).First(&token).Error; err != nil {
+		return nil, ErrInvalidOneTimeToken
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidOneTimeToken
+	}
+	return &token, nil
+}
+
+// markOneTimeTokenUsed marks a token as used; losing this race means the token was redeemed concurrently
+func markOneTimeTokenUsed(tx *gorm.DB, token *models.OneTimeToken) error {
+	result := tx.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidOneTimeToken
+	}
+	return nil
+}
+
+// LastOneTimeTokenIssuedAt returns when the user was last sent a token for the purpose, if ever
+func LastOneTimeTokenIssuedAt(userID uint, purpose string) (*time.Time, error) {
+	var token models.OneTimeToken
+	result := config.GetDB().Where("user_id = ? AND purpose = ?", userID, purpose).Order("created_at DESC").Limit(1).Find(&token)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &token.CreatedAt, nil
+}
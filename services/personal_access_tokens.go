@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrInvalidPersonalAccessToken is returned for unknown, expired or revoked personal access tokens
+	ErrInvalidPersonalAccessToken = errors.New("invalid or expired personal access token")
+	// ErrPersonalAccessTokenNotFound is returned when a token does not exist or belongs to another user
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+)
+
+// CreatePersonalAccessToken stores a new hashed token and returns its raw value, which is only shown once
+func CreatePersonalAccessToken(userID uint, name string, scopes []string, expiresAt *time.Time) (string, *models.PersonalAccessToken, error) {
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	rawToken := models.PersonalAccessTokenPrefix + secret
+
+	token := models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        name,
+		TokenPrefix: rawToken[:len(models.PersonalAccessTokenPrefix)+6],
+		TokenHash:   utils.HashToken(rawToken),
+		Scopes:      strings.Join(scopes, " "),
+		ExpiresAt:   expiresAt,
+	}
+	if err := config.GetDB().Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+
+	return rawToken, &token, nil
+}
+
+// AuthenticatePersonalAccessToken resolves an active token and records its use
+func AuthenticatePersonalAccessToken(rawToken string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := config.GetDB().Where("token_hash = ?", utils.HashToken(rawToken)).First(&token).Error; err != nil {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+
+	if !token.IsActive() {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+
+	// Track last use, writing at most once per touch interval
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= sessionTouchInterval() {
+		token.LastUsedAt = &now
+		if err := config.GetDB().Model(&token).Update("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &token, nil
+}
+
+// ListPersonalAccessTokens returns the user's tokens that have not been revoked
+func ListPersonalAccessTokens(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := config.GetDB().Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokePersonalAccessToken revokes one of the user's tokens
+func RevokePersonalAccessToken(userID, tokenID uint) error {
+	result := config.GetDB().Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// ErrEmailTaken is returned when another account already uses the requested email address
+var ErrEmailTaken = errors.New("email address is already in use")
+
+// UpdateProfile changes the user's display name
+func UpdateProfile(user *models.User, name string) error {
+	user.Name = strings.TrimSpace(name)
+	defer Users.Invalidate(user.ID)
+	return config.GetDB().Model(user).Update("name", user.Name).Error
+}
+
+// ChangePassword sets a new password, signs out every other session and returns
+// fresh tokens for the current one (the password change invalidates its old tokens).
+func ChangePassword(user *models.User, session *models.Session, newPassword string) (*TokenPair, error) {
+	if err := user.SetPassword(newPassword); err != nil {
+		return nil, err
+	}
+
+	defer Users.Invalidate(user.ID)
+	if err := config.GetDB().Model(user).Updates(map[string]interface{}{
+		"password":                user.Password,
+		"tokens_valid_after":      user.TokensValidAfter,
+		"password_reset_required": false,
+		"password_unset":          false,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return signOutOtherSessions(user, session)
+}
+
+// ResetPassword redeems a password reset token and sets the new password in one transaction,
+// then signs out every session of the user
+func ResetPassword(token *models.OneTimeToken, user *models.User, newPassword string) error {
+	user.PasswordResetRequired = false
+	if err := user.SetPassword(newPassword); err != nil {
+		return err
+	}
+
+	defer Users.Invalidate(user.ID)
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := markOneTimeTokenUsed(tx, token); err != nil {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, user.ID)
+	})
+}
+
+// EnsureEmailAvailable checks that no account, including deleted ones, uses the address
+func EnsureEmailAvailable(email string) error {
+	var count int64
+	if err := config.GetDB().Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// ConfirmEmailChange redeems an email change token of the user and swaps the address.
+// Like a password change it signs out every other session and reissues tokens for the current one.
+func ConfirmEmailChange(user *models.User, session *models.Session, rawToken string) (*TokenPair, string, error) {
+	db := config.GetDB()
+
+	var token models.OneTimeToken
+	if err := db.Where("token_hash = ? AND purpose = ? AND user_id = ?", utils.HashToken(rawToken), models.TokenPurposeEmailChange, user.ID).
+		First(&token).Error; err != nil {
+		return nil, "", ErrInvalidOneTimeToken
+	}
+	if _, err := ConsumeOneTimeToken(models.TokenPurposeEmailChange, rawToken); err != nil {
+		return nil, "", err
+	}
+
+	newEmail := token.Payload
+	if err := EnsureEmailAvailable(newEmail); err != nil {
+		return nil, "", err
+	}
+
+	oldEmail := user.Email
+	now := time.Now()
+	user.Email = newEmail
+	user.EmailVerifiedAt = &now
+	user.RevokeIssuedTokens()
+
+	defer Users.Invalidate(user.ID)
+	if err := db.Model(user).Updates(map[string]interface{}{
+		"email":              user.Email,
+		"email_verified_at":  user.EmailVerifiedAt,
+		"tokens_valid_after": user.TokensValidAfter,
+	}).Error; err != nil {
+		return nil, "", err
+	}
+
+	pair, err := signOutOtherSessions(user, session)
+	return pair, oldEmail, err
+}
+
+// signOutOtherSessions revokes all sessions but the current one and reissues its tokens
+func signOutOtherSessions(user *models.User, session *models.Session) (*TokenPair, error) {
+	if _, err := RevokeOtherSessions(user.ID, session.ID); err != nil {
+		return nil, err
+	}
+	return ReissueTokenPair(user, session)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// Revocations is the shared access token revocation store
+var Revocations = NewRevocationStore()
+
+// revocationEntry caches the revocation state of a single jti
+type revocationEntry struct {
+	revoked bool
+	until   time.Time
+}
+
+// RevocationStore tracks revoked access tokens in the database and keeps an
+// in-memory cache in front of it. Revoked entries are cached until the token
+// expires; negative lookups are cached briefly so revocations made by other
+// instances are picked up within REVOCATION_CACHE_TTL_SECONDS. The cache holds
+// at most REVOCATION_CACHE_MAX_ENTRIES entries; evicted jtis are looked up again.
+type RevocationStore struct {
+	mu          sync.RWMutex
+	entries     map[string]revocationEntry
+	negativeTTL time.Duration
+	maxEntries  int
+}
+
+// NewRevocationStore creates an empty revocation store
+func NewRevocationStore() *RevocationStore {
+	return &RevocationStore{
+		entries:     make(map[string]revocationEntry),
+		negativeTTL: utils.GetEnvDuration("REVOCATION_CACHE_TTL_SECONDS", time.Second, 30*time.Second),
+		maxEntries:  utils.GetEnvInt("REVOCATION_CACHE_MAX_ENTRIES", 100000),
+	}
+}
+
+// Revoke marks the token with the given jti as revoked until it expires
+func (s *RevocationStore) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	revoked := models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	if err := config.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+		return err
+	}
+
+	s.set(jti, revocationEntry{revoked: true, until: expiresAt})
+	return nil
+}
+
+// IsRevoked reports whether the token with the given jti has been revoked
+func (s *RevocationStore) IsRevoked(jti string) (bool, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.entries[jti]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.until) {
+		return entry.revoked, nil
+	}
+
+	// Cache miss: consult the database
+	var revoked models.RevokedToken
+	result := config.GetDB().Where("jti = ?", jti).Limit(1).Find(&revoked)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		s.set(jti, revocationEntry{revoked: true, until: revoked.ExpiresAt})
+		return true, nil
+	}
+
+	s.set(jti, revocationEntry{revoked: false, until: now.Add(s.negativeTTL)})
+	return false, nil
+}
+
+// PurgeExpired removes revocations for tokens that have expired anyway
+func (s *RevocationStore) PurgeExpired() error {
+	now := time.Now()
+
+	s.mu.Lock()
+	for jti, entry := range s.entries {
+		if !now.Before(entry.until) {
+			delete(s.entries, jti)
+		}
+	}
+	s.mu.Unlock()
+
+	return config.GetDB().Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
+
+// RunJanitor periodically purges expired revocations until the process exits
+func (s *RevocationStore) RunJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.PurgeExpired(); err != nil {
+			log.Println("Failed to purge expired token revocations:", err)
+		}
+	}
+}
+
+// set stores a cache entry, evicting another one when the cache is full
+func (s *RevocationStore) set(jti string, entry revocationEntry) {
+	if s.maxEntries <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[jti]; !ok && len(s.entries) >= s.maxEntries {
+		evictSampled(s.entries, func(entry revocationEntry) time.Time { return entry.until })
+	}
+	s.entries[jti] = entry
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrRoleNotFound is returned when a role name is unknown
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// EnsureDefaultRoles creates the built-in roles and grants the admin role to ADMIN_EMAILS.
+// Only verified addresses are promoted, so registering one of them does not make anyone an admin.
+func EnsureDefaultRoles() error {
+	db := config.GetDB()
+
+	admin := models.Role{Name: models.RoleAdmin}
+	if err := db.Where(models.Role{Name: models.RoleAdmin}).
+		Attrs(models.Role{Description: "Full administrative access"}).
+		FirstOrCreate(&admin).Error; err != nil {
+		return err
+	}
+
+	// Keep the admin role in sync with newly introduced permissions
+	permissions := strings.Join(models.AllPermissions, " ")
+	if admin.Permissions != permissions {
+		if err := db.Model(&admin).Update("permissions", permissions).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, email := range strings.Split(utils.GetEnv("ADMIN_EMAILS", ""), ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+			log.Printf("ADMIN_EMAILS: no user with email %s yet", email)
+			continue
+		}
+		if !user.IsEmailVerified() {
+			log.Printf("ADMIN_EMAILS: email %s is not verified yet", email)
+			continue
+		}
+		if err := db.Model(&user).Association("Roles").Append(&admin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListRoles returns all roles
+func ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := config.GetDB().Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// AssignRole grants a role to a user and signs them out so new tokens carry the role
+func AssignRole(userID uint, roleName string) (*models.User, error) {
+	return changeUserRole(userID, roleName, func(roles *gorm.Association, role *models.Role) error {
+		return roles.Append(role)
+	})
+}
+
+// RemoveRole takes a role away from a user and signs them out so no token keeps its permissions
+func RemoveRole(userID uint, roleName string) (*models.User, error) {
+	return changeUserRole(userID, roleName, func(roles *gorm.Association, role *models.Role) error {
+		return roles.Delete(role)
+	})
+}
+
+// changeUserRole applies a role change and signs the user out everywhere,
+// since permissions are embedded in the access tokens issued so far
+func changeUserRole(userID uint, roleName string, change func(roles *gorm.Association, role *models.Role) error) (*models.User, error) {
+	user, role, err := findUserAndRole(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer Users.Invalidate(userID)
+	if err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := change(tx.Model(user).Association("Roles"), role); err != nil {
+			return err
+		}
+
+		user.RevokeIssuedTokens()
+		if err := tx.Model(user).Update("tokens_valid_after", user.TokensValidAfter).Error; err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, userID)
+	}); err != nil {
+		return nil, err
+	}
+	return loadUserWithRoles(userID)
+}
+
+// findUserAndRole loads the user and role involved in a role assignment
+func findUserAndRole(userID uint, roleName string) (*models.User, *models.Role, error) {
+	var user models.User
+	if err := config.GetDB().First(&user, userID).Error; err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+
+	var role models.Role
+	if err := config.GetDB().Where("name = ?", roleName).First(&role).Error; err != nil {
+		return nil, nil, ErrRoleNotFound
+	}
+
+	return &user, &role, nil
+}
+
+// loadUserWithRoles loads a user together with their roles
+func loadUserWithRoles(userID uint) (*models.User, error) {
+	var user models.User
+	if err := config.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// ErrSessionNotFound is returned when a session does not exist, is revoked or belongs to another user
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionMeta describes the client a session was started from
+type SessionMeta struct {
+	UserAgent string
+	IPAddress string
+}
+
+// sessionTouchInterval limits how often last-seen timestamps are written (default: 60 seconds)
+func sessionTouchInterval() time.Duration {
+	return utils.GetEnvDuration("SESSION_TOUCH_INTERVAL_SECONDS", time.Second, time.Minute)
+}
+
+// GetActiveSession returns an unrevoked session owned by the user
+func GetActiveSession(userID, sessionID uint) (*models.Session, error) {
+	var session models.Session
+	if err := config.GetDB().Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// TouchSession records activity on a session, writing at most once per touch interval
+func TouchSession(session *models.Session) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval() {
+		return nil
+	}
+
+	session.LastSeenAt = now
+	if err := config.GetDB().Model(session).Update("last_seen_at", now).Error; err != nil {
+		return err
+	}
+
+	// Keep the cached copy in step so the next request does not write again
+	Users.updateSession(*session)
+	return nil
+}
+
+// ListActiveSessions returns the user's sessions that can still be used or refreshed
+func ListActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := config.GetDB().
+		Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-RefreshTokenTTL())).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession signs out a single session of the user
+func RevokeSession(userID, sessionID uint) error {
+	session, err := GetActiveSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	return RevokeTokenFamily(userID, session.FamilyID)
+}
+
+// RevokeOtherSessions signs out every session of the user except the given one
+func RevokeOtherSessions(userID, keepSessionID uint) (int64, error) {
+	return revokeSessionsWhere(userID, config.GetDB().Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID))
+}
+
+// RevokeAllSessions signs out every session of the user
+func RevokeAllSessions(userID uint) (int64, error) {
+	return revokeSessionsWhere(userID, config.GetDB().Where("user_id = ? AND revoked_at IS NULL", userID))
+}
+
+// revokeSessionsWhere revokes all of the user's sessions matching the query
+func revokeSessionsWhere(userID uint, query *gorm.DB) (int64, error) {
+	defer Users.Invalidate(userID)
+
+	var familyIDs []string
+	if err := query.Model(&models.Session{}).Pluck("family_id", &familyIDs).Error; err != nil {
+		return 0, err
+	}
+
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		return revokeFamilies(tx, familyIDs)
+	})
+	return int64(len(familyIDs)), err
+}
+
+// createSession stores a new session with its own refresh token family
+func createSession(tx *gorm.DB, userID uint, meta SessionMeta) (*models.Session, error) {
+	userAgent := meta.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	session := models.Session{
+		UserID:     userID,
+		FamilyID:   uuid.NewString(),
+		UserAgent:  userAgent,
+		IPAddress:  meta.IPAddress,
+		LastSeenAt: time.Now(),
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+)
+
+var (
+	// ErrTagNotFound is returned when a tag does not exist or belongs to another user
+	ErrTagNotFound = errors.New("tag not found")
+	// ErrTagExists is returned when creating or renaming a tag to a name the user already uses
+	ErrTagExists = errors.New("a tag with this name already exists")
+	// ErrInvalidTagName is returned for empty or overlong tag names
+	ErrInvalidTagName = errors.New("tag names must be 1 to 50 characters")
+	// ErrTooManyTags is returned when a note would carry more than maxTagsPerNote tags
+	ErrTooManyTags = errors.New("a note can have at most 20 tags")
+	// ErrMergeTagIntoItself is returned when the source and target of a merge are the same tag
+	ErrMergeTagIntoItself = errors.New("a tag cannot be merged into itself")
+)
+
+const (
+	maxTagNameLength = 50
+	maxTagsPerNote   = 20
+)
+
+// NormalizeTagName trims, lowercases and collapses whitespace so "Work  Items" and "work items" are one tag
+func NormalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if name == "" || utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
+
+// NormalizeTagNames normalizes and deduplicates the tag names of a note. A nil list stays nil
+// so callers can tell "leave the tags alone" from "remove all tags".
+func NormalizeTagNames(names []string) ([]string, error) {
+	if names == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := NormalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+
+	if len(normalized) > maxTagsPerNote {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+// ListTags returns the user's tags sorted by name with the number of notes carrying each
+func ListTags(userID uint) ([]models.TagResponse, error) {
+	tags := []models.TagResponse{}
+	err := taggedNoteCounts(config.GetDB()).
+		Where("tags.user_id = ?", userID).
+		Order("tags.name ASC").
+		Scan(&tags).Error
+	return tags, err
+}
+
+// CreateTag creates an empty tag for the user
+func CreateTag(userID uint, name string) (*models.TagResponse, error) {
+	name, err := NormalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	db := config.GetDB()
+	if err := ensureTagNameFree(db, userID, name, 0); err != nil {
+		return nil, err
+	}
+
+	tag := models.Tag{UserID: userID, Name: name}
+	if err := db.Create(&tag).Error; err != nil {
+		return nil, err
+	}
+
+	response := tag.ToResponse(0)
+	return &response, nil
+}
+
+// RenameTag renames one of the user's tags; use MergeTags to fold it into an existing tag
+func RenameTag(userID, tagID uint, name string) (*models.TagResponse, error) {
+	name, err := NormalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	db := config.GetDB()
+	tag, err := findUserTag(db, userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTagNameFree(db, userID, name, tag.ID); err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(tag).Update("name", name).Error; err != nil {
+		return nil, err
+	}
+	return tagResponse(db, tag)
+}
+
+// MergeTags moves every note tagged with the source tag to the target tag and deletes the source
+func MergeTags(userID, sourceID, targetID uint) (*models.TagResponse, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeTagIntoItself
+	}
+
+	var target *models.Tag
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		source, err := findUserTag(tx, userID, sourceID)
+		if err != nil {
+			return err
+		}
+		if target, err = findUserTag(tx, userID, targetID); err != nil {
+			return err
+		}
+
+		// Retag notes that do not already carry the target tag
+		if err := tx.Exec(
+			"INSERT INTO note_tags (note_id, tag_id) SELECT note_id, ? FROM note_tags WHERE tag_id = ? "+
+				"AND note_id NOT IN (SELECT note_id FROM note_tags WHERE tag_id = ?)",
+			target.ID, source.ID, target.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		return deleteTag(tx, source)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tagResponse(config.GetDB(), target)
+}
+
+// DeleteTag removes one of the user's tags from all notes and deletes it
+func DeleteTag(userID, tagID uint) error {
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		tag, err := findUserTag(tx, userID, tagID)
+		if err != nil {
+			return err
+		}
+		return deleteTag(tx, tag)
+	})
+}
+
+// PreloadTags loads the scope user's tags on the notes the query returns
+func (s *NoteScope) PreloadTags(query *gorm.DB) *gorm.DB {
+	return query.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Where("tags.user_id = ?", s.UserID).Order("tags.name ASC")
+	})
+}
+
+// FilterByTags restricts a notes query to notes carrying any (or, with matchAll, every)
+// one of the scope user's tags with the given normalized names
+func (s *NoteScope) FilterByTags(query *gorm.DB, names []string, matchAll bool) *gorm.DB {
+	tagged := config.GetDB().Table("note_tags").
+		Select("note_tags.note_id").
+		Joins("JOIN tags ON tags.id = note_tags.tag_id").
+		Where("tags.user_id = ? AND tags.name IN ?", s.UserID, names)
+	if matchAll {
+		tagged = tagged.Group("note_tags.note_id").Having("COUNT(DISTINCT tags.id) = ?", len(names))
+	}
+	return query.Where("notes.id IN (?)", tagged)
+}
+
+// SetNoteTags replaces the scope user's tags on the note with the given normalized names,
+// creating missing tags. Tags other workspace members put on the note are left untouched.
+func (s *NoteScope) SetNoteTags(tx *gorm.DB, note *models.Note, names []string) error {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tag := models.Tag{UserID: s.UserID, Name: name}
+		if err := tx.Where(&tag).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := tx.Exec(
+		"DELETE FROM note_tags WHERE note_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ?)",
+		note.ID, s.UserID,
+	).Error; err != nil {
+		return err
+	}
+
+	note.Tags = nil
+	if len(tags) > 0 {
+		if err := tx.Model(note).Omit("Tags.*").Association("Tags").Append(tags); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(note.Tags, func(i, j int) bool { return note.Tags[i].Name < note.Tags[j].Name })
+	return nil
+}
+
+// findUserTag loads a tag owned by the user
+func findUserTag(db *gorm.DB, userID, tagID uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := db.Where("id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// ensureTagNameFree rejects names already used by another of the user's tags
+func ensureTagNameFree(db *gorm.DB, userID uint, name string, exceptID uint) error {
+	var count int64
+	if err := db.Model(&models.Tag{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTagExists
+	}
+	return nil
+}
+
+// deleteTag detaches the tag from all notes and deletes it
+func deleteTag(tx *gorm.DB, tag *models.Tag) error {
+	if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(tag).Error
+}
+
+// tagResponse reloads a tag with its note count
+func tagResponse(db *gorm.DB, tag *models.Tag) (*models.TagResponse, error) {
+	var response models.TagResponse
+	if err := taggedNoteCounts(db).Where("tags.id = ?", tag.ID).Scan(&response).Error; err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// taggedNoteCounts selects tags with the number of (not deleted) notes carrying them
+func taggedNoteCounts(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Tag{}).
+		Select("tags.id, tags.name, tags.created_at, COUNT(notes.id) AS note_count").
+		Joins("LEFT JOIN note_tags ON note_tags.tag_id = tags.id").
+		Joins("LEFT JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL").
+		Group("tags.id, tags.name, tags.created_at")
+}
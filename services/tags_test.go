@@ -0,0 +1,73 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"notes-api/config"
+	"notes-api/models"
+)
+
+// createTaggedNote creates a personal note of the scope user and tags it
+func createTaggedNote(t *testing.T, scope *NoteScope, title string, tags ...string) *models.Note {
+	t.Helper()
+
+	note := models.Note{Title: title, Content: title, UserID: scope.UserID}
+	if err := config.GetDB().Create(&note).Error; err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+	if err := scope.SetNoteTags(config.GetDB(), &note, tags); err != nil {
+		t.Fatalf("SetNoteTags() error = %v", err)
+	}
+	return &note
+}
+
+func TestFilterByTags(t *testing.T) {
+	setupTestDB(t)
+	alice := &NoteScope{UserID: createTestUser(t, "alice@example.com").ID}
+	bob := &NoteScope{UserID: createTestUser(t, "bob@example.com").ID}
+
+	createTaggedNote(t, alice, "report", "work", "urgent")
+	createTaggedNote(t, alice, "meeting", "work")
+	groceries := createTaggedNote(t, alice, "groceries", "home")
+	createTaggedNote(t, alice, "untagged")
+	createTaggedNote(t, bob, "bob's work", "work")
+
+	// Bob's tags on Alice's note must not count for Alice's filters
+	if err := bob.SetNoteTags(config.GetDB(), groceries, []string{"urgent"}); err != nil {
+		t.Fatalf("SetNoteTags() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		matchAll bool
+		want     string
+	}{
+		{name: "any of one tag", tags: []string{"work"}, want: "meeting,report"},
+		{name: "any of two tags", tags: []string{"work", "home"}, want: "groceries,meeting,report"},
+		{name: "all of one tag", tags: []string{"work"}, matchAll: true, want: "meeting,report"},
+		{name: "all of two tags", tags: []string{"work", "urgent"}, matchAll: true, want: "report"},
+		{name: "all of tags no note has together", tags: []string{"work", "home"}, matchAll: true},
+		{name: "any of an unknown tag", tags: []string{"missing"}},
+		{name: "any including an unknown tag", tags: []string{"home", "missing"}, want: "groceries"},
+		{name: "all including an unknown tag", tags: []string{"work", "missing"}, matchAll: true},
+		{name: "any ignores other users' tags", tags: []string{"urgent"}, want: "report"},
+		{name: "all ignores other users' tags", tags: []string{"home", "urgent"}, matchAll: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titles []string
+			if err := alice.FilterByTags(alice.Notes(), tt.tags, tt.matchAll).Pluck("title", &titles).Error; err != nil {
+				t.Fatalf("query notes: %v", err)
+			}
+			sort.Strings(titles)
+
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Errorf("FilterByTags(%v, matchAll=%v) = %q, want %q", tt.tags, tt.matchAll, got, tt.want)
+			}
+		})
+	}
+}
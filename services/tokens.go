@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// TokenPair represents the access and refresh tokens handed to a client
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	// SessionID identifies the session the tokens belong to (cookie sessions derive their CSRF token from it)
+	SessionID uint `json:"-"`
+}
+
+// RefreshTokenTTL returns how long refresh tokens stay valid (default: 30 days)
+func RefreshTokenTTL() time.Duration {
+	return utils.GetEnvDuration("REFRESH_TOKEN_EXPIRATION_DAYS", 24*time.Hour, 30*24*time.Hour)
+}
+
+// IssueTokenPair starts a new session for the user and issues its first token pair
+func IssueTokenPair(user *models.User, meta SessionMeta) (*TokenPair, error) {
+	var pair *TokenPair
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		session, err := createSession(tx, user.ID, meta)
+		if err != nil {
+			return err
+		}
+
+		pair, _, err = issueTokenPair(tx, user, session)
+		return err
+	})
+	return pair, err
+}
+
+// ReissueTokenPair issues a fresh token pair for an existing session, revoking the session's
+// earlier refresh tokens. Used after credential changes bump the user's token watermark.
+func ReissueTokenPair(user *models.User, session *models.Session) (*TokenPair, error) {
+	var pair *TokenPair
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", session.FamilyID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, _, err = issueTokenPair(tx, user, session)
+		return err
+	})
+	return pair, err
+}
+
+// RotateRefreshToken exchanges a refresh token for a new token pair.
+// Presenting a token that was already rotated revokes its whole family.
+func RotateRefreshToken(rawToken string) (*models.User, *TokenPair, error) {
+	db := config.GetDB()
+
+	// Find refresh token together with its owner
+	var token models.RefreshToken
+	if err := db.Preload("User").Where("token_hash = ?", utils.HashToken(rawToken)).First(&token).Error; err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// A rotated token being replayed means it has leaked
+	if token.UsedAt != nil && token.RevokedAt == nil {
+		if err := RevokeTokenFamily(token.UserID, token.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	// Password changes invalidate refresh tokens issued before them as well
+	if !token.IsActive() || token.User.ID == 0 || token.User.IsDisabled() || token.User.TokenIssuedBeforeWatermark(token.CreatedAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// The session owning the token family must still be active
+	var session models.Session
+	if err := db.Where("family_id = ? AND revoked_at IS NULL", token.FamilyID).First(&session).Error; err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var pair *TokenPair
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Mark the token as used; losing this race means another request rotated it first
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", token.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var next *models.RefreshToken
+		var err error
+		pair, next, err = issueTokenPair(tx, &token.User, &session)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&session).Update("last_seen_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&token).Update("replaced_by_id", next.ID).Error
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := RevokeTokenFamily(token.UserID, token.FamilyID); revokeErr != nil {
+			return nil, nil, revokeErr
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &token.User, pair, nil
+}
+
+// FindRefreshTokenSession returns the active session a refresh token belongs to without rotating it
+func FindRefreshTokenSession(rawToken string) (*models.Session, error) {
+	db := config.GetDB()
+
+	var token models.RefreshToken
+	if err := db.Where("token_hash = ?", utils.HashToken(rawToken)).First(&token).Error; err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var session models.Session
+	if err := db.Where("family_id = ? AND revoked_at IS NULL", token.FamilyID).First(&session).Error; err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return &session, nil
+}
+
+// RevokeTokenFamily revokes every refresh token descending from the same login along with its session
+func RevokeTokenFamily(userID uint, familyID string) error {
+	defer Users.Invalidate(userID)
+
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		return revokeFamilies(tx, []string{familyID})
+	})
+}
+
+// RevokeRefreshToken revokes the family of a refresh token owned by the given user
+func RevokeRefreshToken(userID uint, rawToken string) error {
+	var token models.RefreshToken
+	if err := config.GetDB().Where("token_hash = ? AND user_id = ?", utils.HashToken(rawToken), userID).First(&token).Error; err != nil {
+		return ErrInvalidRefreshToken
+	}
+	return RevokeTokenFamily(token.UserID, token.FamilyID)
+}
+
+// issueTokenPair signs an access token and stores a new refresh token in the session's family
+func issueTokenPair(tx *gorm.DB, user *models.User, session *models.Session) (*TokenPair, *models.RefreshToken, error) {
+	// Roles are embedded in the access token so permission checks need no lookup
+	var roles []models.Role
+	if err := tx.Model(user).Association("Roles").Find(&roles); err != nil {
+		return nil, nil, err
+	}
+	user.Roles = roles
+
+	accessToken, err := utils.GenerateJWT(utils.TokenSubject{
+		UserID:      user.ID,
+		Email:       user.Email,
+		SessionID:   session.ID,
+		Roles:       user.RoleNames(),
+		Permissions: user.PermissionList(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawRefreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  session.FamilyID,
+		TokenHash: utils.HashToken(rawRefreshToken),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+		SessionID:    session.ID,
+	}, &refreshToken, nil
+}
+
+// revokeFamilies revokes the refresh tokens and sessions belonging to the given families
+func revokeFamilies(tx *gorm.DB, familyIDs []string) error {
+	if len(familyIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("family_id IN ? AND revoked_at IS NULL", familyIDs).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Session{}).
+		Where("family_id IN ? AND revoked_at IS NULL", familyIDs).
+		Update("revoked_at", now).Error
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare returns the refresh token the client presents
+		prepare func(t *testing.T, user *models.User, pair *TokenPair) string
+		wantErr error
+		// wantFamilyRevoked reports whether the whole session must be signed out afterwards
+		wantFamilyRevoked bool
+	}{
+		{
+			name: "current token",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				return pair.RefreshToken
+			},
+		},
+		{
+			name: "rotated token replayed",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				if _, _, err := RotateRefreshToken(pair.RefreshToken); err != nil {
+					t.Fatalf("first RotateRefreshToken() error = %v", err)
+				}
+				return pair.RefreshToken
+			},
+			wantErr:           ErrRefreshTokenReused,
+			wantFamilyRevoked: true,
+		},
+		{
+			name: "unknown token",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				return "not-a-refresh-token"
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				updateRefreshToken(t, pair.RefreshToken, "expires_at", time.Now().Add(-time.Minute))
+				return pair.RefreshToken
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "revoked session",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				if err := RevokeSession(user.ID, pair.SessionID); err != nil {
+					t.Fatalf("RevokeSession() error = %v", err)
+				}
+				return pair.RefreshToken
+			},
+			wantErr:           ErrInvalidRefreshToken,
+			wantFamilyRevoked: true,
+		},
+		{
+			name: "disabled user",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				config.GetDB().Model(user).Update("disabled_at", time.Now())
+				return pair.RefreshToken
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "issued before a password change",
+			prepare: func(t *testing.T, user *models.User, pair *TokenPair) string {
+				config.GetDB().Model(user).Update("tokens_valid_after", time.Now().Add(time.Second))
+				return pair.RefreshToken
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			user := createTestUser(t, "alice@example.com")
+
+			pair, err := IssueTokenPair(user, SessionMeta{})
+			if err != nil {
+				t.Fatalf("IssueTokenPair() error = %v", err)
+			}
+			presented := tt.prepare(t, user, pair)
+
+			rotatedUser, rotated, err := RotateRefreshToken(presented)
+			if err != tt.wantErr {
+				t.Fatalf("RotateRefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				if rotatedUser.ID != user.ID || rotated.SessionID != pair.SessionID {
+					t.Errorf("RotateRefreshToken() = user %d, session %d, want user %d, session %d",
+						rotatedUser.ID, rotated.SessionID, user.ID, pair.SessionID)
+				}
+				if rotated.RefreshToken == pair.RefreshToken {
+					t.Error("RotateRefreshToken() returned the presented refresh token")
+				}
+
+				// The presented token is used up and points at its replacement
+				old := findRefreshToken(t, presented)
+				next := findRefreshToken(t, rotated.RefreshToken)
+				if old.UsedAt == nil || old.ReplacedByID == nil || *old.ReplacedByID != next.ID || next.FamilyID != old.FamilyID {
+					t.Errorf("rotated token = %+v, replacement = %+v", old, next)
+				}
+			}
+
+			var activeTokens, activeSessions int64
+			config.GetDB().Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&activeTokens)
+			config.GetDB().Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&activeSessions)
+			if revoked := activeTokens == 0 && activeSessions == 0; revoked != tt.wantFamilyRevoked {
+				t.Errorf("family revoked = %v (%d active tokens, %d active sessions), want %v",
+					revoked, activeTokens, activeSessions, tt.wantFamilyRevoked)
+			}
+		})
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesDescendants(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "alice@example.com")
+
+	pair, err := IssueTokenPair(user, SessionMeta{})
+	if err != nil {
+		t.Fatalf("IssueTokenPair() error = %v", err)
+	}
+
+	// The legitimate client rotates twice...
+	_, second, err := RotateRefreshToken(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+	_, third, err := RotateRefreshToken(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+
+	// ...then a stolen copy of the first token is replayed
+	if _, _, err := RotateRefreshToken(pair.RefreshToken); err != ErrRefreshTokenReused {
+		t.Fatalf("replayed RotateRefreshToken() error = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// The newest token of the family is revoked along with the session
+	if _, _, err := RotateRefreshToken(third.RefreshToken); err != ErrInvalidRefreshToken {
+		t.Errorf("RotateRefreshToken() of the newest token error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := GetActiveSession(user.ID, pair.SessionID); err == nil {
+		t.Error("the session survived refresh token reuse")
+	}
+}
+
+// findRefreshToken loads the stored record of a raw refresh token
+func findRefreshToken(t *testing.T, rawToken string) *models.RefreshToken {
+	t.Helper()
+
+	var token models.RefreshToken
+	if err := config.GetDB().Where("token_hash = ?", utils.HashToken(rawToken)).First(&token).Error; err != nil {
+		t.Fatalf("load refresh token: %v", err)
+	}
+	return &token
+}
+
+// updateRefreshToken changes a column of the stored record of a raw refresh token
+func updateRefreshToken(t *testing.T, rawToken, column string, value interface{}) {
+	t.Helper()
+
+	if err := config.GetDB().Model(&models.RefreshToken{}).
+		Where("token_hash = ?", utils.HashToken(rawToken)).
+		Update(column, value).Error; err != nil {
+		t.Fatalf("update refresh token: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already uses 2FA
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnrolled is returned when confirming without a pending enrollment
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment has not been started")
+	// ErrTwoFactorNotEnabled is returned when disabling 2FA for a user who does not use it
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidTwoFactorCode is returned for wrong, replayed or used codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// BeginTOTPEnrollment stores a new pending TOTP secret and returns it with its otpauth URI
+func BeginTOTPEnrollment(user *models.User) (string, string, error) {
+	if user.IsTwoFactorEnabled() {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	defer Users.Invalidate(user.ID)
+	if err := config.GetDB().Model(user).Updates(map[string]interface{}{
+		"totp_secret":     secret,
+		"totp_enabled_at": nil,
+		"totp_last_step":  0,
+	}).Error; err != nil {
+		return "", "", err
+	}
+
+	issuer := utils.GetEnv("TOTP_ISSUER", "Notes API")
+	return secret, utils.TOTPURI(issuer, user.Email, secret), nil
+}
+
+// ConfirmTOTPEnrollment enables 2FA once the user proves their app produces valid codes.
+// It returns freshly generated recovery codes which are only shown once.
+func ConfirmTOTPEnrollment(user *models.User, code string) ([]string, error) {
+	if user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	defer Users.Invalidate(user.ID)
+
+	var codes []string
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled_at": now,
+			"totp_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP turns off 2FA and discards the secret and recovery codes
+func DisableTOTP(user *models.User) error {
+	if !user.IsTwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	defer Users.Invalidate(user.ID)
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// VerifySecondFactor accepts a TOTP code or an unused recovery code for the user.
+// Each TOTP code and each recovery code can only be used once.
+func VerifySecondFactor(user *models.User, code string) error {
+	if !user.IsTwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	db := config.GetDB()
+
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		// Only move forward in time so an intercepted code cannot be replayed
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		Users.Invalidate(user.ID)
+		return nil
+	}
+
+	codeHash, err := utils.HashRecoveryCode(user.ID, normalizeRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes discards existing recovery codes and stores a new set hashed with the server secret
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		code := raw[:4] + "-" + raw[4:]
+		codeHash, err := utils.HashRecoveryCode(userID, normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: codeHash})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and separator insensitive
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// enrollTestUser enables TOTP for the user and returns the step its confirmation code used
+// along with the recovery codes
+func enrollTestUser(t *testing.T, user *models.User) (int64, []string) {
+	t.Helper()
+
+	if _, _, err := BeginTOTPEnrollment(user); err != nil {
+		t.Fatalf("BeginTOTPEnrollment() error = %v", err)
+	}
+	user, err := Users.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	step := utils.TOTPStep(time.Now())
+	recoveryCodes, err := ConfirmTOTPEnrollment(user, totpCode(t, user.TOTPSecret, step))
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment() error = %v", err)
+	}
+	return step, recoveryCodes
+}
+
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	code, err := utils.TOTPCode(secret, step)
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	return code
+}
+
+func TestVerifySecondFactorRejectsReplays(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "alice@example.com")
+	step, recoveryCodes := enrollTestUser(t, user)
+	_, otherRecoveryCodes := enrollTestUser(t, createTestUser(t, "bob@example.com"))
+
+	tests := []struct {
+		name    string
+		code    func(secret string) string
+		wantErr error
+	}{
+		{
+			name:    "code used to confirm the enrollment",
+			code:    func(secret string) string { return totpCode(t, secret, step) },
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "code of the next step",
+			code: func(secret string) string { return totpCode(t, secret, step+1) },
+		},
+		{
+			name:    "same code again",
+			code:    func(secret string) string { return totpCode(t, secret, step+1) },
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name:    "earlier code after a later one",
+			code:    func(secret string) string { return totpCode(t, secret, step) },
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "recovery code",
+			code: func(secret string) string { return recoveryCodes[0] },
+		},
+		{
+			name:    "recovery code of another user",
+			code:    func(secret string) string { return otherRecoveryCodes[0] },
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "recovery code in upper case without separator",
+			code: func(secret string) string { return strings.ToUpper(strings.ReplaceAll(recoveryCodes[1], "-", "")) },
+		},
+		{
+			name:    "same recovery code again",
+			code:    func(secret string) string { return recoveryCodes[0] },
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name:    "wrong code",
+			code:    func(secret string) string { return "ABCDEF" },
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+	}
+
+	// The cases run in order, each seeing the codes used before it
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, err := Users.Get(user.ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if err := VerifySecondFactor(current, tt.code(current.TOTPSecret)); err != tt.wantErr {
+				t.Fatalf("VerifySecondFactor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
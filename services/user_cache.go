@@ -0,0 +1,208 @@
+package services
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+// Users is the shared cache of users loaded by the authentication middleware
+var Users = NewUserCache()
+
+func init() {
+	// Served at /debug/vars when METRICS_ENABLED is set
+	expvar.Publish("user_cache", expvar.Func(func() interface{} {
+		return Users.Stats()
+	}))
+}
+
+// userCacheEntry caches a single user record and the active sessions it was seen with
+type userCacheEntry struct {
+	user     models.User
+	sessions map[uint]models.Session
+	until    time.Time
+}
+
+// UserCacheStats reports the effectiveness of the user cache
+type UserCacheStats struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRate       float64 `json:"hit_rate"`
+	Evictions     uint64  `json:"evictions"`
+	Invalidations uint64  `json:"invalidations"`
+	Size          int     `json:"size"`
+	MaxEntries    int     `json:"max_entries"`
+}
+
+// UserCache keeps recently authenticated users and their active sessions in memory so
+// protected requests do not hit the database for every call. Entries live for
+// USER_CACHE_TTL_SECONDS and at most USER_CACHE_MAX_ENTRIES users are kept (0 disables the
+// cache). Changes and session revocations made through this instance invalidate the entry
+// immediately; changes made by other instances are picked up once the entry expires.
+type UserCache struct {
+	mu         sync.RWMutex
+	entries    map[uint]userCacheEntry
+	ttl        time.Duration
+	maxEntries int
+
+	// generation is bumped by every invalidation so a lookup that raced with a
+	// change does not put the stale record back into the cache
+	generation atomic.Uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+// NewUserCache creates an empty user cache
+func NewUserCache() *UserCache {
+	return &UserCache{
+		entries:    make(map[uint]userCacheEntry),
+		ttl:        utils.GetEnvDuration("USER_CACHE_TTL_SECONDS", time.Second, 30*time.Second),
+		maxEntries: utils.GetEnvInt("USER_CACHE_MAX_ENTRIES", 10000),
+	}
+}
+
+// Get returns the user with the given ID from the cache or the database.
+// The returned user is a copy the caller may modify.
+func (c *UserCache) Get(userID uint) (*models.User, error) {
+	now := time.Now()
+
+	if c.maxEntries > 0 {
+		c.mu.RLock()
+		entry, ok := c.entries[userID]
+		c.mu.RUnlock()
+		if ok && now.Before(entry.until) {
+			c.hits.Add(1)
+			user := entry.user
+			return &user, nil
+		}
+	}
+
+	// Cache miss: consult the database
+	c.misses.Add(1)
+	generation := c.generation.Load()
+
+	var user models.User
+	if err := config.GetDB().First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	c.set(userID, user, now.Add(c.ttl), generation)
+	return &user, nil
+}
+
+// Session returns an active session of the user from the cache or the database.
+// Sessions are only cached alongside their cached user and share its expiry.
+func (c *UserCache) Session(userID, sessionID uint) (*models.Session, error) {
+	now := time.Now()
+
+	if c.maxEntries > 0 {
+		c.mu.RLock()
+		entry, ok := c.entries[userID]
+		session, found := entry.sessions[sessionID]
+		c.mu.RUnlock()
+		if ok && found && now.Before(entry.until) {
+			return &session, nil
+		}
+	}
+
+	generation := c.generation.Load()
+
+	loaded, err := GetActiveSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setSession(userID, *loaded, generation)
+	return loaded, nil
+}
+
+// Invalidate drops the cached record and sessions of a user after they changed
+func (c *UserCache) Invalidate(userID uint) {
+	c.generation.Add(1)
+	c.invalidations.Add(1)
+
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+// Stats returns the cache counters and the current hit rate
+func (c *UserCache) Stats() UserCacheStats {
+	c.mu.RLock()
+	size := len(c.entries)
+	c.mu.RUnlock()
+
+	stats := UserCacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Evictions:     c.evictions.Load(),
+		Invalidations: c.invalidations.Load(),
+		Size:          size,
+		MaxEntries:    c.maxEntries,
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// set stores a user unless the cache is disabled or a user was invalidated since the lookup started
+func (c *UserCache) set(userID uint, user models.User, until time.Time, generation uint64) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation.Load() != generation {
+		return
+	}
+
+	if _, ok := c.entries[userID]; !ok && len(c.entries) >= c.maxEntries {
+		evictSampled(c.entries, func(entry userCacheEntry) time.Time { return entry.until })
+		c.evictions.Add(1)
+	}
+	c.entries[userID] = userCacheEntry{user: user, until: until}
+}
+
+// setSession stores a session next to its cached user unless the user was invalidated since the lookup started
+func (c *UserCache) setSession(userID uint, session models.Session, generation uint64) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok || c.generation.Load() != generation {
+		return
+	}
+
+	if entry.sessions == nil {
+		entry.sessions = make(map[uint]models.Session)
+		c.entries[userID] = entry
+	}
+	entry.sessions[session.ID] = session
+}
+
+// updateSession refreshes a cached session after its activity was recorded
+func (c *UserCache) updateSession(session models.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[session.UserID]; ok {
+		if _, cached := entry.sessions[session.ID]; cached {
+			entry.sessions[session.ID] = session
+		}
+	}
+}
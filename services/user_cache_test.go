@@ -0,0 +1,309 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"notes-api/config"
+	"notes-api/models"
+)
+
+func TestUserCacheInvalidate(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "alice@example.com")
+
+	if _, err := Users.Get(user.ID); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	// Changes made behind the cache's back are not seen until the entry is invalidated
+	if err := config.GetDB().Model(user).Update("name", "Renamed").Error; err != nil {
+		t.Fatalf("rename user: %v", err)
+	}
+	cached, err := Users.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if cached.Name != "Test User" {
+		t.Fatalf("Get() name = %q, want the cached record", cached.Name)
+	}
+
+	Users.Invalidate(user.ID)
+	fresh, err := Users.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if fresh.Name != "Renamed" {
+		t.Fatalf("Get() after Invalidate() name = %q, want %q", fresh.Name, "Renamed")
+	}
+
+	stats := Users.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Invalidations != 1 {
+		t.Errorf("Stats() = %+v, want 1 hit, 2 misses and 1 invalidation", stats)
+	}
+}
+
+func TestUserCacheReturnsCopies(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "alice@example.com")
+
+	first, err := Users.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	first.Name = "Changed by caller"
+
+	second, err := Users.Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if second.Name != "Test User" {
+		t.Fatalf("Get() name = %q, modifying a returned user changed the cache", second.Name)
+	}
+}
+
+func TestUserCacheGenerationRace(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate bool
+		wantCached bool
+	}{
+		{name: "lookup without concurrent change", wantCached: true},
+		{name: "lookup raced with invalidation", invalidate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &UserCache{entries: make(map[uint]userCacheEntry), ttl: time.Minute, maxEntries: 10}
+
+			// A lookup records the generation before reading the database...
+			generation := cache.generation.Load()
+			if tt.invalidate {
+				// ...while a change commits and invalidates the user
+				cache.Invalidate(1)
+			}
+			cache.set(1, models.User{ID: 1}, time.Now().Add(time.Minute), generation)
+
+			if _, cached := cache.entries[1]; cached != tt.wantCached {
+				t.Errorf("user cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestUserCacheEviction(t *testing.T) {
+	cache := &UserCache{entries: make(map[uint]userCacheEntry), ttl: time.Minute, maxEntries: 3}
+
+	for id := uint(1); id <= 10; id++ {
+		cache.set(id, models.User{ID: id}, time.Now().Add(time.Minute), cache.generation.Load())
+	}
+
+	stats := cache.Stats()
+	if stats.Size != 3 {
+		t.Errorf("Stats().Size = %d, want 3", stats.Size)
+	}
+	if stats.Evictions != 7 {
+		t.Errorf("Stats().Evictions = %d, want 7", stats.Evictions)
+	}
+	if _, ok := cache.entries[10]; !ok {
+		t.Error("the most recently stored user was evicted")
+	}
+}
+
+func TestUserCacheDisabled(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv("USER_CACHE_MAX_ENTRIES", "0")
+	Users = NewUserCache()
+	user := createTestUser(t, "alice@example.com")
+
+	for i := 0; i < 2; i++ {
+		if _, err := Users.Get(user.ID); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+
+	if stats := Users.Stats(); stats.Hits != 0 || stats.Size != 0 {
+		t.Errorf("Stats() = %+v, want no hits and no entries", stats)
+	}
+}
+
+func TestUserCacheSessionRevocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(user *models.User, session *models.Session) error
+	}{
+		{
+			name: "RevokeSession",
+			revoke: func(user *models.User, session *models.Session) error {
+				return RevokeSession(user.ID, session.ID)
+			},
+		},
+		{
+			name: "RevokeAllSessions",
+			revoke: func(user *models.User, session *models.Session) error {
+				_, err := RevokeAllSessions(user.ID)
+				return err
+			},
+		},
+		{
+			name: "RevokeOtherSessions",
+			revoke: func(user *models.User, session *models.Session) error {
+				_, err := RevokeOtherSessions(user.ID, session.ID+1)
+				return err
+			},
+		},
+		{
+			name: "RevokeTokenFamily",
+			revoke: func(user *models.User, session *models.Session) error {
+				return RevokeTokenFamily(user.ID, session.FamilyID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			user := createTestUser(t, "alice@example.com")
+
+			pair, err := IssueTokenPair(user, SessionMeta{UserAgent: "test"})
+			if err != nil {
+				t.Fatalf("IssueTokenPair() error = %v", err)
+			}
+
+			// Load the user and the session into the cache
+			if _, err := Users.Get(user.ID); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			session, err := Users.Session(user.ID, pair.SessionID)
+			if err != nil {
+				t.Fatalf("Session() error = %v", err)
+			}
+			if _, err := Users.Session(user.ID, pair.SessionID); err != nil {
+				t.Fatalf("cached Session() error = %v", err)
+			}
+
+			if err := tt.revoke(user, session); err != nil {
+				t.Fatalf("revoke error = %v", err)
+			}
+
+			if _, err := Users.Session(user.ID, pair.SessionID); err == nil {
+				t.Fatal("Session() returned a revoked session")
+			}
+		})
+	}
+}
+
+func TestUserCacheTouchSession(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv("SESSION_TOUCH_INTERVAL_SECONDS", "0")
+	user := createTestUser(t, "alice@example.com")
+
+	pair, err := IssueTokenPair(user, SessionMeta{})
+	if err != nil {
+		t.Fatalf("IssueTokenPair() error = %v", err)
+	}
+	if _, err := Users.Get(user.ID); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	session, err := Users.Session(user.ID, pair.SessionID)
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+
+	session.LastSeenAt = session.LastSeenAt.Add(-time.Hour)
+	if err := TouchSession(session); err != nil {
+		t.Fatalf("TouchSession() error = %v", err)
+	}
+
+	cached, err := Users.Session(user.ID, pair.SessionID)
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+	if !cached.LastSeenAt.Equal(session.LastSeenAt) {
+		t.Errorf("cached LastSeenAt = %v, want %v", cached.LastSeenAt, session.LastSeenAt)
+	}
+}
+
+func BenchmarkUserCacheGet(b *testing.B) {
+	setupTestDB(b)
+	user := createTestUser(b, "alice@example.com")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := Users.Get(user.ID); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkUserCacheGetManyUsers(b *testing.B) {
+	setupTestDB(b)
+	var ids []uint
+	for i := 0; i < 100; i++ {
+		ids = append(ids, createTestUser(b, fmt.Sprintf("user%d@example.com", i)).ID)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := Users.Get(ids[i%len(ids)]); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkUserCacheGetWithInvalidations(b *testing.B) {
+	setupTestDB(b)
+	user := createTestUser(b, "alice@example.com")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			// Every hundredth request changes the user
+			if i%100 == 0 {
+				Users.Invalidate(user.ID)
+			}
+			if _, err := Users.Get(user.ID); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkUserCacheSession(b *testing.B) {
+	setupTestDB(b)
+	user := createTestUser(b, "alice@example.com")
+	pair, err := IssueTokenPair(user, SessionMeta{})
+	if err != nil {
+		b.Fatalf("IssueTokenPair() error = %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := Users.Get(user.ID); err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := Users.Session(user.ID, pair.SessionID); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+)
+
+var (
+	// ErrCannotManageSelf is returned when administrators try to lock themselves out
+	ErrCannotManageSelf = errors.New("administrators cannot disable or delete their own account")
+	// ErrUserNotDeleted is returned when restoring a user who was never deleted
+	ErrUserNotDeleted = errors.New("user is not deleted")
+)
+
+// UserFilter describes an admin user listing query
+type UserFilter struct {
+	Search  string
+	Status  string
+	Page    int
+	PerPage int
+}
+
+// ListUsers returns a page of users matching the filter together with the total count.
+// Deleted users are only included when filtering by the deleted status.
+func ListUsers(filter UserFilter) ([]models.User, int64, error) {
+	query := config.GetDB().Model(&models.User{})
+
+	switch filter.Status {
+	case models.UserStatusActive:
+		query = query.Where("disabled_at IS NULL")
+	case models.UserStatusDisabled:
+		query = query.Where("disabled_at IS NOT NULL")
+	case models.UserStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := containsPattern(search)
+		query = query.Where("name LIKE ? ESCAPE '!' OR email LIKE ? ESCAPE '!'", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := query.Preload("Roles").
+		Offset((filter.Page - 1) * filter.PerPage).
+		Limit(filter.PerPage).
+		Order("created_at DESC").
+		Find(&users).Error
+	return users, total, err
+}
+
+// likeEscaper escapes LIKE wildcards using '!' as the escape character, which needs no
+// quoting in either MySQL or SQLite string literals
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// containsPattern builds a LIKE pattern matching values that contain search literally
+func containsPattern(search string) string {
+	return "%" + likeEscaper.Replace(search) + "%"
+}
+
+// CountNotesByUser returns the number of notes owned by each of the given users
+func CountNotesByUser(userIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID uint
+		Count  int64
+	}
+	if err := config.GetDB().Model(&models.Note{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ?", userIDs).
+		Group("user_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetUser loads any user, including deleted ones, together with their roles
+func GetUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := config.GetDB().Unscoped().Preload("Roles").First(&user, userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// DisableUser blocks the user from signing in and signs out all of their sessions
+func DisableUser(actorID, userID uint) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotManageSelf
+	}
+
+	if err := updateUserAndSignOut(userID, map[string]interface{}{"disabled_at": time.Now()}); err != nil {
+		return nil, err
+	}
+	return GetUser(userID)
+}
+
+// EnableUser allows a disabled user to sign in again
+func EnableUser(userID uint) (*models.User, error) {
+	defer Users.Invalidate(userID)
+
+	result := config.GetDB().Model(&models.User{}).Where("id = ?", userID).Update("disabled_at", nil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrUserNotFound
+	}
+	return GetUser(userID)
+}
+
+// ForcePasswordReset signs the user out everywhere and blocks password logins until they reset it
+func ForcePasswordReset(userID uint) (*models.User, error) {
+	if err := updateUserAndSignOut(userID, map[string]interface{}{"password_reset_required": true}); err != nil {
+		return nil, err
+	}
+	return GetUser(userID)
+}
+
+// DeleteUser soft-deletes the user and signs out all of their sessions
+func DeleteUser(actorID, userID uint) error {
+	if actorID == userID {
+		return ErrCannotManageSelf
+	}
+
+	defer Users.Invalidate(userID)
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.User{}, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		return revokeUserSessions(tx, userID)
+	})
+}
+
+// RestoreUser undoes a soft delete
+func RestoreUser(userID uint) (*models.User, error) {
+	user, err := GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.DeletedAt.Valid {
+		return nil, ErrUserNotDeleted
+	}
+
+	defer Users.Invalidate(userID)
+	if err := config.GetDB().Unscoped().Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	return GetUser(userID)
+}
+
+// updateUserAndSignOut updates the user, invalidates issued access tokens and revokes every session
+func updateUserAndSignOut(userID uint, updates map[string]interface{}) error {
+	defer Users.Invalidate(userID)
+
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return ErrUserNotFound
+		}
+
+		user.RevokeIssuedTokens()
+		updates["tokens_valid_after"] = user.TokensValidAfter
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, userID)
+	})
+}
+
+// revokeUserSessions revokes every session and refresh token family of the user
+func revokeUserSessions(tx *gorm.DB, userID uint) error {
+	var familyIDs []string
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("family_id", &familyIDs).Error; err != nil {
+		return err
+	}
+	return revokeFamilies(tx, familyIDs)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+	"notes-api/utils"
+)
+
+var (
+	// ErrInvalidInvitation is returned for unknown, expired, revoked or already accepted invitations
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	// ErrInvitationNotFound is returned when a workspace has no pending invitation with the given ID
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationEmailMismatch is returned when someone accepts an invitation sent to another address
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+)
+
+// WorkspaceInvitationTTL returns how long invitation links stay valid (default: 7 days)
+func WorkspaceInvitationTTL() time.Duration {
+	return utils.GetEnvDuration("WORKSPACE_INVITATION_EXPIRATION_HOURS", time.Hour, 7*24*time.Hour)
+}
+
+// CreateWorkspaceInvitation invites an email address to the actor's workspace and returns the raw
+// link token. Owners and admins may invite members; only the owner may invite admins. Earlier
+// pending invitations for the same address are revoked.
+func CreateWorkspaceInvitation(actor *models.WorkspaceMember, email, role string) (*models.WorkspaceInvitation, string, error) {
+	if !actor.CanManageMembers() || (role == models.WorkspaceRoleAdmin && !actor.IsOwner()) {
+		return nil, "", ErrWorkspaceForbidden
+	}
+
+	email = strings.TrimSpace(email)
+	db := config.GetDB()
+
+	// Existing members do not need an invitation
+	var count int64
+	if err := db.Model(&models.WorkspaceMember{}).
+		Joins("JOIN users ON users.id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ? AND users.email = ?", actor.WorkspaceID, email).
+		Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count > 0 {
+		return nil, "", ErrAlreadyWorkspaceMember
+	}
+
+	rawToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	invitation := models.WorkspaceInvitation{
+		WorkspaceID: actor.WorkspaceID,
+		Email:       email,
+		Role:        role,
+		TokenHash:   utils.HashToken(rawToken),
+		InvitedByID: &actor.UserID,
+		ExpiresAt:   now.Add(WorkspaceInvitationTTL()),
+		LastSentAt:  now,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := pendingInvitations(tx, actor.WorkspaceID).
+			Where("email = ?", email).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+		return tx.First(&invitation.Workspace, actor.WorkspaceID).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &invitation, rawToken, nil
+}
+
+// ListPendingWorkspaceInvitations returns the workspace's invitations that can still be accepted
+func ListPendingWorkspaceInvitations(workspaceID uint) ([]models.WorkspaceInvitation, error) {
+	var invitations []models.WorkspaceInvitation
+	err := pendingInvitations(config.GetDB(), workspaceID).
+		Where("expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// RevokeWorkspaceInvitation cancels a pending invitation (owners and admins only)
+func RevokeWorkspaceInvitation(actor *models.WorkspaceMember, invitationID uint) error {
+	if !actor.CanManageMembers() {
+		return ErrWorkspaceForbidden
+	}
+
+	result := pendingInvitations(config.GetDB(), actor.WorkspaceID).
+		Where("id = ?", invitationID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// ResendWorkspaceInvitation replaces the link token of a pending or expired invitation,
+// restarts its expiry and returns the new raw token (owners and admins only)
+func ResendWorkspaceInvitation(actor *models.WorkspaceMember, invitationID uint) (*models.WorkspaceInvitation, string, error) {
+	if !actor.CanManageMembers() {
+		return nil, "", ErrWorkspaceForbidden
+	}
+
+	var invitation models.WorkspaceInvitation
+	if err := pendingInvitations(config.GetDB(), actor.WorkspaceID).
+		Preload("Workspace").
+		Where("id = ?", invitationID).
+		First(&invitation).Error; err != nil {
+		return nil, "", ErrInvitationNotFound
+	}
+
+	rawToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	invitation.TokenHash = utils.HashToken(rawToken)
+	invitation.ExpiresAt = now.Add(WorkspaceInvitationTTL())
+	invitation.LastSentAt = now
+	if err := config.GetDB().Model(&invitation).Updates(map[string]interface{}{
+		"token_hash":   invitation.TokenHash,
+		"expires_at":   invitation.ExpiresAt,
+		"last_sent_at": invitation.LastSentAt,
+	}).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &invitation, rawToken, nil
+}
+
+// FindWorkspaceInvitation looks up a pending invitation of an existing workspace by its raw link token
+func FindWorkspaceInvitation(rawToken string) (*models.WorkspaceInvitation, error) {
+	var invitation models.WorkspaceInvitation
+	if err := config.GetDB().Joins("Workspace").
+		Where("workspace_invitations.token_hash = ?", utils.HashToken(rawToken)).
+		First(&invitation).Error; err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	// The workspace may have been deleted since the invitation was sent
+	if invitation.Workspace.ID == 0 || invitation.Status() != models.InvitationStatusPending {
+		return nil, ErrInvalidInvitation
+	}
+	return &invitation, nil
+}
+
+// PreviewWorkspaceInvitation describes a pending invitation to the holder of its link
+func PreviewWorkspaceInvitation(rawToken string) (*models.InvitationPreviewResponse, error) {
+	invitation, err := FindWorkspaceInvitation(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := config.GetDB().Model(&models.User{}).Where("email = ?", invitation.Email).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.InvitationPreviewResponse{
+		WorkspaceName: invitation.Workspace.Name,
+		Email:         invitation.Email,
+		Role:          invitation.Role,
+		ExpiresAt:     invitation.ExpiresAt,
+		AccountExists: count > 0,
+	}, nil
+}
+
+// AcceptWorkspaceInvitation adds the user to the invited workspace. The invitation must have been
+// sent to the user's email address and can only be accepted once.
+func AcceptWorkspaceInvitation(user *models.User, rawToken string) (*models.WorkspaceMember, *models.WorkspaceInvitation, error) {
+	invitation, err := FindWorkspaceInvitation(rawToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, nil, ErrInvitationEmailMismatch
+	}
+
+	var member *models.WorkspaceMember
+	err = config.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Mark as accepted; losing this race means the invitation was accepted concurrently
+		result := pendingInvitations(tx, invitation.WorkspaceID).
+			Where("id = ?", invitation.ID).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidInvitation
+		}
+
+		member, err = addWorkspaceMember(tx, invitation.WorkspaceID, user, invitation.Role)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return member, invitation, nil
+}
+
+// pendingInvitations queries the workspace's invitations that were neither accepted nor revoked
+func pendingInvitations(db *gorm.DB, workspaceID uint) *gorm.DB {
+	return db.Model(&models.WorkspaceInvitation{}).
+		Where("workspace_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", workspaceID)
+}
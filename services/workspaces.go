@@ -0,0 +1,323 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"notes-api/config"
+	"notes-api/models"
+)
+
+var (
+	// ErrWorkspaceNotFound is returned when a workspace does not exist or the user is not a member
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrWorkspaceMemberNotFound is returned when the user is not a member of the workspace
+	ErrWorkspaceMemberNotFound = errors.New("workspace member not found")
+	// ErrWorkspaceForbidden is returned when the member's role does not allow the action
+	ErrWorkspaceForbidden = errors.New("insufficient workspace role")
+	// ErrAlreadyWorkspaceMember is returned when adding a user who already belongs to the workspace
+	ErrAlreadyWorkspaceMember = errors.New("user is already a member of the workspace")
+	// ErrWorkspaceOwnerImmutable is returned when trying to remove, demote or leave as the owner
+	ErrWorkspaceOwnerImmutable = errors.New("the workspace owner cannot be removed or demoted")
+)
+
+// NoteScope restricts note queries to a user's personal workspace or to a shared workspace they belong to
+type NoteScope struct {
+	UserID uint
+	// Membership is nil for the personal workspace
+	Membership *models.WorkspaceMember
+}
+
+// ResolveNoteScope returns the scope for the user's notes in the workspace, or in their personal
+// workspace when workspaceID is nil. Non-members get ErrWorkspaceNotFound.
+func ResolveNoteScope(userID uint, workspaceID *uint) (*NoteScope, error) {
+	scope := &NoteScope{UserID: userID}
+	if workspaceID == nil {
+		return scope, nil
+	}
+
+	membership, err := GetWorkspaceMembership(*workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	scope.Membership = membership
+	return scope, nil
+}
+
+// WorkspaceID returns the workspace notes in this scope belong to (nil for the personal workspace)
+func (s *NoteScope) WorkspaceID() *uint {
+	if s.Membership == nil {
+		return nil
+	}
+	return &s.Membership.WorkspaceID
+}
+
+// Notes returns a query over the notes visible in the scope
+func (s *NoteScope) Notes() *gorm.DB {
+	query := config.GetDB().Model(&models.Note{})
+	if s.Membership == nil {
+		return query.Where("notes.user_id = ? AND notes.workspace_id IS NULL", s.UserID)
+	}
+	return query.Where("notes.workspace_id = ?", s.Membership.WorkspaceID)
+}
+
+// AuditDetails describes the scope's workspace for audit events
+func (s *NoteScope) AuditDetails() string {
+	if s.Membership == nil {
+		return ""
+	}
+	return fmt.Sprintf("workspace %d", s.Membership.WorkspaceID)
+}
+
+// CanModify reports whether the user may edit or delete the note. Workspace members
+// may change their own notes; owners and admins may change any note in the workspace.
+func (s *NoteScope) CanModify(note *models.Note) bool {
+	return s.canModifyCreatedBy(note.UserID)
+}
+
+// canModifyCreatedBy applies the CanModify rule to anything in the scope created by the given user
+func (s *NoteScope) canModifyCreatedBy(userID uint) bool {
+	if s.Membership == nil || userID == s.UserID {
+		return true
+	}
+	return s.Membership.CanManageMembers()
+}
+
+// CreateWorkspace creates a workspace owned by the user
+func CreateWorkspace(userID uint, name string) (*models.Workspace, error) {
+	workspace := models.Workspace{Name: strings.TrimSpace(name)}
+
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      userID,
+			Role:        models.WorkspaceRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// ListWorkspaces returns the workspaces the user belongs to with their role and member counts
+func ListWorkspaces(userID uint) ([]models.WorkspaceResponse, error) {
+	var memberships []models.WorkspaceMember
+	if err := activeMemberships(config.GetDB()).
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspace_members.created_at ASC").
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	workspaceIDs := make([]uint, 0, len(memberships))
+	for _, membership := range memberships {
+		workspaceIDs = append(workspaceIDs, membership.WorkspaceID)
+	}
+
+	var workspaces []models.Workspace
+	if err := config.GetDB().Where("id IN ?", workspaceIDs).Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+	counts, err := countWorkspaceMembers(workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Workspace, len(workspaces))
+	for _, workspace := range workspaces {
+		byID[workspace.ID] = workspace
+	}
+
+	responses := make([]models.WorkspaceResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		workspace := byID[membership.WorkspaceID]
+		responses = append(responses, workspace.ToResponse(membership.Role, counts[workspace.ID]))
+	}
+	return responses, nil
+}
+
+// GetWorkspace returns the workspace as seen by one of its members
+func GetWorkspace(membership *models.WorkspaceMember) (*models.WorkspaceResponse, error) {
+	var workspace models.Workspace
+	if err := config.GetDB().First(&workspace, membership.WorkspaceID).Error; err != nil {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	counts, err := countWorkspaceMembers([]uint{workspace.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	response := workspace.ToResponse(membership.Role, counts[workspace.ID])
+	return &response, nil
+}
+
+// GetWorkspaceMembership returns the user's membership of an existing workspace
+func GetWorkspaceMembership(workspaceID, userID uint) (*models.WorkspaceMember, error) {
+	var membership models.WorkspaceMember
+	if err := activeMemberships(config.GetDB()).
+		Where("workspace_members.workspace_id = ? AND workspace_members.user_id = ?", workspaceID, userID).
+		First(&membership).Error; err != nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	return &membership, nil
+}
+
+// RenameWorkspace changes the workspace name (owners and admins only)
+func RenameWorkspace(actor *models.WorkspaceMember, name string) (*models.WorkspaceResponse, error) {
+	if !actor.CanManageMembers() {
+		return nil, ErrWorkspaceForbidden
+	}
+
+	if err := config.GetDB().Model(&models.Workspace{}).
+		Where("id = ?", actor.WorkspaceID).
+		Update("name", strings.TrimSpace(name)).Error; err != nil {
+		return nil, err
+	}
+	return GetWorkspace(actor)
+}
+
+// DeleteWorkspace deletes the workspace together with its notes, notebooks and pending invitations (owner only)
+func DeleteWorkspace(actor *models.WorkspaceMember) error {
+	if !actor.IsOwner() {
+		return ErrWorkspaceForbidden
+	}
+
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workspace_id = ?", actor.WorkspaceID).Delete(&models.Note{}).Error; err != nil {
+			return err
+		}
+		if err := deleteAllNotebooks(tx, &NoteScope{UserID: actor.UserID, Membership: actor}); err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", actor.WorkspaceID).Delete(&models.WorkspaceInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Workspace{}, actor.WorkspaceID).Error
+	})
+}
+
+// ListWorkspaceMembers returns the members of the workspace, owner first
+func ListWorkspaceMembers(workspaceID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	err := config.GetDB().Preload("User").
+		Where("workspace_id = ?", workspaceID).
+		Order("CASE role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// UpdateWorkspaceMemberRole changes a member's role (owner only; the owner's role cannot change)
+func UpdateWorkspaceMemberRole(actor *models.WorkspaceMember, userID uint, role string) (*models.WorkspaceMember, error) {
+	if !actor.IsOwner() {
+		return nil, ErrWorkspaceForbidden
+	}
+
+	member, err := findWorkspaceMember(actor.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member.IsOwner() {
+		return nil, ErrWorkspaceOwnerImmutable
+	}
+
+	member.Role = role
+	if err := config.GetDB().Model(member).Update("role", role).Error; err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveWorkspaceMember removes a member from the workspace. Members may leave on their own;
+// admins may remove members and the owner may remove anyone but themselves.
+func RemoveWorkspaceMember(actor *models.WorkspaceMember, userID uint) error {
+	member, err := findWorkspaceMember(actor.WorkspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case member.IsOwner():
+		return ErrWorkspaceOwnerImmutable
+	case member.UserID == actor.UserID:
+		// Leaving the workspace
+	case !actor.CanManageMembers():
+		return ErrWorkspaceForbidden
+	case member.Role == models.WorkspaceRoleAdmin && !actor.IsOwner():
+		return ErrWorkspaceForbidden
+	}
+
+	return config.GetDB().Delete(member).Error
+}
+
+// addWorkspaceMember adds the user to the workspace with the given role
+func addWorkspaceMember(tx *gorm.DB, workspaceID uint, user *models.User, role string) (*models.WorkspaceMember, error) {
+	var count int64
+	if err := tx.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, user.ID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyWorkspaceMember
+	}
+
+	member := models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		User:        *user,
+		Role:        role,
+	}
+	if err := tx.Omit("User").Create(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// findWorkspaceMember loads a member of the workspace with their user
+func findWorkspaceMember(workspaceID, userID uint) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	if err := config.GetDB().Preload("User").
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&member).Error; err != nil {
+		return nil, ErrWorkspaceMemberNotFound
+	}
+	return &member, nil
+}
+
+// activeMemberships queries memberships of workspaces that have not been deleted
+func activeMemberships(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.WorkspaceMember{}).
+		Joins("JOIN workspaces ON workspaces.id = workspace_members.workspace_id AND workspaces.deleted_at IS NULL")
+}
+
+// countWorkspaceMembers returns the number of members of each of the given workspaces
+func countWorkspaceMembers(workspaceIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(workspaceIDs))
+	if len(workspaceIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		WorkspaceID uint
+		Count       int64
+	}
+	if err := config.GetDB().Model(&models.WorkspaceMember{}).
+		Select("workspace_id, COUNT(*) AS count").
+		Where("workspace_id IN ?", workspaceIDs).
+		Group("workspace_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.WorkspaceID] = row.Count
+	}
+	return counts, nil
+}
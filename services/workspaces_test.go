@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"notes-api/config"
+	"notes-api/models"
+)
+
+// createTestWorkspace creates a workspace owned by the user and returns the owner's membership
+func createTestWorkspace(tb testing.TB, owner *models.User, name string) *models.WorkspaceMember {
+	tb.Helper()
+
+	workspace, err := CreateWorkspace(owner.ID, name)
+	if err != nil {
+		tb.Fatalf("CreateWorkspace() error = %v", err)
+	}
+	membership, err := GetWorkspaceMembership(workspace.ID, owner.ID)
+	if err != nil {
+		tb.Fatalf("GetWorkspaceMembership() error = %v", err)
+	}
+	return membership
+}
+
+func TestDeleteWorkspace(t *testing.T) {
+	setupTestDB(t)
+	owner := createTestUser(t, "alice@example.com")
+	actor := createTestWorkspace(t, owner, "Team")
+	scope := &NoteScope{UserID: owner.ID, Membership: actor}
+
+	parent := createTestNotebook(t, scope, "projects", nil)
+	child := createTestNotebook(t, scope, "archive", &parent.ID)
+	note := models.Note{Title: "plan", Content: "plan", UserID: owner.ID, WorkspaceID: &actor.WorkspaceID, NotebookID: &child.ID}
+	if err := config.GetDB().Create(&note).Error; err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+	if _, _, err := CreateWorkspaceInvitation(actor, "bob@example.com", models.WorkspaceRoleMember); err != nil {
+		t.Fatalf("CreateWorkspaceInvitation() error = %v", err)
+	}
+
+	// A personal notebook of the owner is left alone
+	personal := createTestNotebook(t, &NoteScope{UserID: owner.ID}, "personal", nil)
+
+	if err := DeleteWorkspace(actor); err != nil {
+		t.Fatalf("DeleteWorkspace() error = %v", err)
+	}
+
+	db := config.GetDB()
+	var notes, notebooks, invitations int64
+	db.Model(&models.Note{}).Where("workspace_id = ?", actor.WorkspaceID).Count(&notes)
+	db.Model(&models.Notebook{}).Where("workspace_id = ?", actor.WorkspaceID).Count(&notebooks)
+	db.Model(&models.WorkspaceInvitation{}).Where("workspace_id = ?", actor.WorkspaceID).Count(&invitations)
+	if notes != 0 || notebooks != 0 || invitations != 0 {
+		t.Errorf("left %d notes, %d notebooks and %d invitations of the deleted workspace", notes, notebooks, invitations)
+	}
+	if err := db.First(&models.Notebook{}, personal.ID).Error; err != nil {
+		t.Errorf("personal notebook deleted with the workspace: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"sync"
+)
+
+var (
+	csrfKey     []byte
+	csrfKeyOnce sync.Once
+)
+
+// CSRFToken derives the CSRF token of a cookie session as an HMAC of the session ID,
+// so a token planted by another site or taken from another session is rejected
+func CSRFToken(sessionID uint) string {
+	mac := hmac.New(sha256.New, csrfSecret())
+	mac.Write([]byte("csrf:" + strconv.FormatUint(uint64(sessionID), 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCSRFToken reports whether the token was derived for the session
+func VerifyCSRFToken(sessionID uint, token string) bool {
+	return hmac.Equal([]byte(token), []byte(CSRFToken(sessionID)))
+}
+
+// csrfSecret returns the key CSRF tokens are derived with: CSRF_SECRET, else JWT_SECRET,
+// else a random key that only works while a single instance is running
+func csrfSecret() []byte {
+	csrfKeyOnce.Do(func() {
+		if secret := GetEnv("CSRF_SECRET", GetEnv("JWT_SECRET", "")); secret != "" {
+			csrfKey = []byte(secret)
+			return
+		}
+
+		csrfKey = make([]byte, 32)
+		if _, err := rand.Read(csrfKey); err != nil {
+			log.Fatal("Failed to generate CSRF key:", err)
+		}
+		log.Println("CSRF_SECRET is not set; CSRF tokens are only valid on this instance until it restarts")
+	})
+	return csrfKey
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv returns the value of an environment variable or the fallback if it is empty
+func GetEnv(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvInt returns an environment variable parsed as an int or the fallback
+func GetEnvInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvBool returns an environment variable parsed as a bool or the fallback
+func GetEnvBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key))); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvDuration returns an environment variable holding a whole number of units as a duration
+func GetEnvDuration(key string, unit time.Duration, fallback time.Duration) time.Duration {
+	if value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil && value > 0 {
+		return time.Duration(value) * unit
+	}
+	return fallback
+}
@@ -2,86 +2,135 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// JWTClaims represents the JWT claims
+// Token uses distinguish access tokens from intermediate tokens signed with the same key
+const (
+	TokenUseAccess     = "access"
+	TokenUseMFAPending = "mfa_pending"
+)
+
+// JWTClaims represents the JWT claims.
+// RegisteredClaims.ID carries the jti used for server-side revocation.
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID      uint     `json:"user_id"`
+	Email       string   `json:"email"`
+	SessionID   uint     `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	TokenUse    string   `json:"token_use"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT generates a new JWT token for the user
-func GenerateJWT(userID uint, email string) (string, error) {
-	// Get JWT secret from environment
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET not set in environment")
-	}
+// TokenSubject describes the user and session an access token is issued for
+type TokenSubject struct {
+	UserID      uint
+	Email       string
+	SessionID   uint
+	Roles       []string
+	Permissions []string
+}
 
-	// Get JWT expiration time (default: 24 hours)
-	expirationHours := 24
-	if expStr := os.Getenv("JWT_EXPIRATION_HOURS"); expStr != "" {
-		if exp, err := strconv.Atoi(expStr); err == nil {
-			expirationHours = exp
+// HasPermission reports whether the token grants the permission
+func (c *JWTClaims) HasPermission(permission string) bool {
+	for _, granted := range c.Permissions {
+		if granted == permission {
+			return true
 		}
 	}
+	return false
+}
+
+// AccessTokenTTL returns how long access tokens stay valid (default: 15 minutes).
+// JWT_EXPIRATION_HOURS is still honoured for deployments that have not migrated yet.
+func AccessTokenTTL() time.Duration {
+	if ttl := GetEnvDuration("JWT_ACCESS_EXPIRATION_MINUTES", time.Minute, 0); ttl > 0 {
+		return ttl
+	}
+	return GetEnvDuration("JWT_EXPIRATION_HOURS", time.Hour, 15*time.Minute)
+}
+
+// MFATokenTTL returns how long a user has to complete the second factor (default: 5 minutes)
+func MFATokenTTL() time.Duration {
+	return GetEnvDuration("MFA_TOKEN_EXPIRATION_MINUTES", time.Minute, 5*time.Minute)
+}
 
-	// Create claims
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
+// GenerateJWT generates a new short-lived access token for the user
+func GenerateJWT(subject TokenSubject) (string, error) {
+	claims := newClaims(subject.UserID, subject.Email, TokenUseAccess, AccessTokenTTL())
+	claims.SessionID = subject.SessionID
+	claims.Roles = subject.Roles
+	claims.Permissions = subject.Permissions
+	return signClaims(claims)
+}
+
+// ValidateJWT validates and parses an access token
+func ValidateJWT(tokenString string) (*JWTClaims, error) {
+	return parseClaims(tokenString, TokenUseAccess)
+}
+
+// GenerateMFAToken generates a short-lived token proving the password step of a login succeeded
+func GenerateMFAToken(userID uint, email string) (string, error) {
+	return signClaims(newClaims(userID, email, TokenUseMFAPending, MFATokenTTL()))
+}
+
+// ValidateMFAToken validates and parses an "mfa pending" token
+func ValidateMFAToken(tokenString string) (*JWTClaims, error) {
+	return parseClaims(tokenString, TokenUseMFAPending)
+}
+
+// newClaims builds the claims shared by all tokens issued for a user
+func newClaims(userID uint, email, tokenUse string, ttl time.Duration) *JWTClaims {
+	now := time.Now()
+	return &JWTClaims{
+		UserID:   userID,
+		Email:    email,
+		TokenUse: tokenUse,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expirationHours))),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "notes-api",
 			Subject:   strconv.Itoa(int(userID)),
+			ID:        uuid.NewString(),
 		},
 	}
+}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign token with secret
-	tokenString, err := token.SignedString([]byte(secret))
+// signClaims signs the claims with the active key of the key ring
+func signClaims(claims *JWTClaims) (string, error) {
+	// Load signing keys
+	ring, err := LoadKeyRing()
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
+	return ring.Sign(claims)
 }
 
-// ValidateJWT validates and parses a JWT token
-func ValidateJWT(tokenString string) (*JWTClaims, error) {
-	// Get JWT secret from environment
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, errors.New("JWT_SECRET not set in environment")
+// parseClaims validates a token and makes sure it was issued for the expected use
+func parseClaims(tokenString, tokenUse string) (*JWTClaims, error) {
+	// Load verification keys
+	ring, err := LoadKeyRing()
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse token
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(secret), nil
-	})
-
+	// Parse token (the key ring picks the key by kid and signing method)
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, ring.Keyfunc, jwt.WithValidMethods(ring.ValidMethods()))
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate token and extract claims
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	// Validate token and extract claims (tokens without a jti cannot be revoked)
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.ID != "" && claims.TokenUse == tokenUse {
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
-}
\ No newline at end of file
+}
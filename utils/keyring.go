@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing keys
+const minRSAKeyBits = 2048
+
+// SigningKey is a single asymmetric key of the key ring
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.PrivateKey
+	PublicKey crypto.PublicKey
+}
+
+// KeyRing holds the keys used to sign and verify access tokens.
+// The first asymmetric key signs new tokens; the others only verify tokens issued before a rotation.
+// Without asymmetric keys tokens are signed with the shared HS256 secret.
+type KeyRing struct {
+	Keys   []SigningKey
+	Secret []byte
+}
+
+// JSONWebKey is the public part of a signing key as published in the JWKS document
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the JWKS document served to other services
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var keyRingCache struct {
+	sync.Mutex
+	source string
+	ring   *KeyRing
+}
+
+// LoadKeyRing returns the key ring configured through JWT_KEYS and JWT_SECRET.
+// JWT_KEYS is a comma separated list of kid=path entries pointing at PEM files; the first entry
+// must be a private key and signs new tokens. The ring is reloaded when the configuration changes.
+func LoadKeyRing() (*KeyRing, error) {
+	keys := os.Getenv("JWT_KEYS")
+	secret := os.Getenv("JWT_SECRET")
+	source := keys + "\x00" + secret
+
+	keyRingCache.Lock()
+	defer keyRingCache.Unlock()
+
+	if keyRingCache.ring != nil && keyRingCache.source == source {
+		return keyRingCache.ring, nil
+	}
+
+	ring, err := parseKeyRing(keys, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRingCache.source = source
+	keyRingCache.ring = ring
+	return ring, nil
+}
+
+// parseKeyRing loads the configured key files
+func parseKeyRing(keys, secret string) (*KeyRing, error) {
+	ring := &KeyRing{}
+	if secret != "" {
+		ring.Secret = []byte(secret)
+	}
+
+	seen := make(map[string]bool)
+	for i, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(entry, "=")
+		kid, path = strings.TrimSpace(kid), strings.TrimSpace(path)
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("JWT_KEYS entry %q must have the form kid=path", entry)
+		}
+		if seen[kid] {
+			return nil, fmt.Errorf("JWT_KEYS contains key ID %q more than once", kid)
+		}
+		seen[kid] = true
+
+		key, err := loadSigningKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 && key.Private == nil {
+			return nil, fmt.Errorf("JWT_KEYS: the first key %q signs tokens and must be a private key", kid)
+		}
+		ring.Keys = append(ring.Keys, *key)
+	}
+
+	if len(ring.Keys) == 0 && ring.Secret == nil {
+		return nil, errors.New("neither JWT_KEYS nor JWT_SECRET is set in environment")
+	}
+
+	return ring, nil
+}
+
+// loadSigningKey reads a PEM encoded private or public key
+func loadSigningKey(kid, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %q: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q is not PEM encoded", kid)
+	}
+
+	key := &SigningKey{ID: kid}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", kid, err)
+		}
+		key.Private = private
+		key.PublicKey = private.(crypto.Signer).Public()
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", kid, err)
+		}
+		key.Private = private
+		key.PublicKey = private.Public()
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", kid, err)
+		}
+		key.PublicKey = public
+	default:
+		return nil, fmt.Errorf("key %q has unsupported PEM type %q", kid, block.Type)
+	}
+
+	switch public := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key %q must be at least %d bits", kid, minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("key %q must be an RSA or Ed25519 key", kid)
+	}
+
+	return key, nil
+}
+
+// Sign signs the claims with the active key, adding its kid header
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	if len(r.Keys) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(r.Secret)
+	}
+
+	active := r.Keys[0]
+	token := jwt.NewWithClaims(active.Method, claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.Private)
+}
+
+// Keyfunc resolves the verification key of a token by its kid and algorithm.
+// HS256 tokens are only accepted while JWT_SECRET is configured so deployments can migrate gradually.
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if r.Secret == nil {
+			return nil, errors.New("invalid signing method")
+		}
+		return r.Secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range r.Keys {
+		if key.ID == kid {
+			if key.Method.Alg() != token.Method.Alg() {
+				return nil, errors.New("invalid signing method")
+			}
+			return key.PublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// ValidMethods lists the algorithms the ring can verify
+func (r *KeyRing) ValidMethods() []string {
+	methods := make([]string, 0, 3)
+	if r.Secret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	for _, key := range r.Keys {
+		if !containsString(methods, key.Method.Alg()) {
+			methods = append(methods, key.Method.Alg())
+		}
+	}
+	return methods
+}
+
+// JWKS returns the public keys of the ring; the shared HS256 secret is never published
+func (r *KeyRing) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(r.Keys))}
+	for _, key := range r.Keys {
+		jwk := JSONWebKey{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// containsString reports whether the slice contains the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
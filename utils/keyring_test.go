@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys holds PEM files of an RSA and an Ed25519 key pair
+type testKeys struct {
+	rsa        *rsa.PrivateKey
+	rsaPrivate string
+	rsaPublic  string
+	ed25519    ed25519.PrivateKey
+	edPrivate  string
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+
+	dir := t.TempDir()
+	return &testKeys{
+		rsa:        rsaKey,
+		rsaPrivate: writePrivateKey(t, dir, "rsa.pem", rsaKey),
+		rsaPublic:  writePublicKey(t, dir, "rsa.pub", rsaKey.Public()),
+		ed25519:    edKey,
+		edPrivate:  writePrivateKey(t, dir, "ed25519.pem", edKey),
+	}
+}
+
+func writePrivateKey(t *testing.T, dir, name string, key crypto.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal private key: %v", err)
+	}
+	return writePEM(t, dir, name, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, name string, key crypto.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return writePEM(t, dir, name, "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return path
+}
+
+// useKeys configures the key ring for the rest of the test
+func useKeys(t *testing.T, keys, secret string) {
+	t.Helper()
+
+	t.Setenv("JWT_KEYS", keys)
+	t.Setenv("JWT_SECRET", secret)
+}
+
+// issueTestToken signs an access token with the configured key ring
+func issueTestToken(t *testing.T) string {
+	t.Helper()
+
+	token, err := GenerateJWT(TokenSubject{UserID: 1, Email: "alice@example.com", SessionID: 1})
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	return token
+}
+
+// tokenHeader returns the alg and kid headers of a token
+func tokenHeader(t *testing.T, token string) (string, string) {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return parsed.Method.Alg(), kid
+}
+
+func TestLoadKeyRingErrors(t *testing.T) {
+	keys := newTestKeys(t)
+	dir := t.TempDir()
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	small := writePrivateKey(t, dir, "small.pem", smallKey)
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    string
+		secret  string
+		wantErr string
+	}{
+		{name: "nothing configured", wantErr: "neither JWT_KEYS nor JWT_SECRET"},
+		{name: "entry without kid", keys: keys.rsaPrivate, wantErr: "kid=path"},
+		{name: "duplicate kid", keys: "a=" + keys.rsaPrivate + ",a=" + keys.edPrivate, wantErr: "more than once"},
+		{name: "public key signing", keys: "a=" + keys.rsaPublic, wantErr: "must be a private key"},
+		{name: "missing file", keys: "a=" + filepath.Join(dir, "missing.pem"), wantErr: "failed to read"},
+		{name: "not PEM encoded", keys: "a=" + notPEM, wantErr: "not PEM encoded"},
+		{name: "RSA key too small", keys: "a=" + small, wantErr: "at least 2048 bits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useKeys(t, tt.keys, tt.secret)
+
+			_, err := LoadKeyRing()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadKeyRing() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	keys := newTestKeys(t)
+
+	// 1. Shared secret only
+	useKeys(t, "", "test-secret")
+	hmacToken := issueTestToken(t)
+	if alg, _ := tokenHeader(t, hmacToken); alg != "HS256" {
+		t.Fatalf("token alg = %s, want HS256", alg)
+	}
+
+	// 2. An RSA key signs new tokens while the secret keeps older tokens valid
+	useKeys(t, "rsa-1="+keys.rsaPrivate, "test-secret")
+	rsaToken := issueTestToken(t)
+	if alg, kid := tokenHeader(t, rsaToken); alg != "RS256" || kid != "rsa-1" {
+		t.Fatalf("token alg = %s, kid = %s, want RS256 signed by rsa-1", alg, kid)
+	}
+
+	// 3. Rotation to an Ed25519 key; the RSA key only verifies and the secret is retired
+	useKeys(t, "ed-2="+keys.edPrivate+",rsa-1="+keys.rsaPublic, "")
+	edToken := issueTestToken(t)
+	if alg, kid := tokenHeader(t, edToken); alg != "EdDSA" || kid != "ed-2" {
+		t.Fatalf("token alg = %s, kid = %s, want EdDSA signed by ed-2", alg, kid)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		wantOK bool
+	}{
+		{name: "token of the active key", token: edToken, wantOK: true},
+		{name: "token of the retired RSA key", token: rsaToken, wantOK: true},
+		{name: "token of the retired secret", token: hmacToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateJWT(tt.token)
+			if (err == nil) != tt.wantOK {
+				t.Errorf("ValidateJWT() error = %v, want valid = %v", err, tt.wantOK)
+			}
+		})
+	}
+
+	// 4. Dropping the RSA key from the ring invalidates its tokens
+	useKeys(t, "ed-2="+keys.edPrivate, "")
+	if _, err := ValidateJWT(rsaToken); err == nil {
+		t.Error("ValidateJWT() accepted a token of a key removed from the ring")
+	}
+	if _, err := ValidateJWT(edToken); err != nil {
+		t.Errorf("ValidateJWT() of the active key's token error = %v", err)
+	}
+}
+
+func TestKeyRingRejectsForgedTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	useKeys(t, "rsa-1="+keys.rsaPrivate, "")
+
+	claims := newClaims(1, "alice@example.com", TokenUseAccess, AccessTokenTTL())
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+
+	publicPEM, err := os.ReadFile(keys.rsaPublic)
+	if err != nil {
+		t.Fatalf("read public key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "HS256 keyed with the public key", token: sign(jwt.SigningMethodHS256, "rsa-1", publicPEM)},
+		{name: "none algorithm", token: sign(jwt.SigningMethodNone, "rsa-1", jwt.UnsafeAllowNoneSignatureType)},
+		{name: "EdDSA under the RSA kid", token: sign(jwt.SigningMethodEdDSA, "rsa-1", keys.ed25519)},
+		{name: "unknown kid", token: sign(jwt.SigningMethodRS256, "rsa-2", keys.rsa)},
+		{name: "missing kid", token: sign(jwt.SigningMethodRS256, "", keys.rsa)},
+		{name: "other key under the known kid", token: sign(jwt.SigningMethodRS256, "rsa-1", otherKey)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateJWT(tt.token); err == nil {
+				t.Error("ValidateJWT() accepted a forged token")
+			}
+		})
+	}
+
+	if _, err := ValidateJWT(sign(jwt.SigningMethodRS256, "rsa-1", keys.rsa)); err != nil {
+		t.Errorf("ValidateJWT() of a genuine token error = %v", err)
+	}
+}
+
+func TestKeyRingJWKS(t *testing.T) {
+	keys := newTestKeys(t)
+	useKeys(t, "ed-2="+keys.edPrivate+",rsa-1="+keys.rsaPublic, "test-secret")
+
+	ring, err := LoadKeyRing()
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+	set := ring.JWKS()
+
+	want := []JSONWebKey{
+		{
+			Kty: "OKP", Kid: "ed-2", Use: "sig", Alg: "EdDSA", Crv: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(keys.ed25519.Public().(ed25519.PublicKey)),
+		},
+		{
+			Kty: "RSA", Kid: "rsa-1", Use: "sig", Alg: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(keys.rsa.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(keys.rsa.E)).Bytes()),
+		},
+	}
+	if len(set.Keys) != len(want) {
+		t.Fatalf("JWKS() has %d keys, want %d (the shared secret must not be published)", len(set.Keys), len(want))
+	}
+	for i := range want {
+		if set.Keys[i] != want[i] {
+			t.Errorf("JWKS() key %d = %+v, want %+v", i, set.Keys[i], want[i])
+		}
+	}
+
+	if methods := strings.Join(ring.ValidMethods(), ","); methods != "HS256,EdDSA,RS256" {
+		t.Errorf("ValidMethods() = %s, want HS256,EdDSA,RS256", methods)
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownPasswordHash is returned for stored hashes in an unrecognised format
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes passwords into self-describing strings
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the password
+	Hash(password string) (string, error)
+	// Verify checks the password against a hash produced by this hasher and reports
+	// whether the hash was created with parameters other than the current ones
+	Verify(password, encoded string) (ok bool, outdated bool, err error)
+	// Recognizes reports whether the encoded hash was produced by this kind of hasher
+	Recognizes(encoded string) bool
+}
+
+// Argon2idHasher hashes passwords with argon2id into PHC strings:
+// $argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// BcryptHasher hashes passwords with bcrypt at the given cost
+type BcryptHasher struct {
+	Cost int
+}
+
+var phcEncoding = base64.RawStdEncoding
+
+// CurrentPasswordHasher returns the hasher configured by PASSWORD_HASHER (argon2id or bcrypt).
+// Defaults follow the OWASP recommendation for argon2id (m=64 MiB, t=3, p=2).
+func CurrentPasswordHasher() PasswordHasher {
+	if strings.ToLower(GetEnv("PASSWORD_HASHER", "argon2id")) == "bcrypt" {
+		return BcryptHasher{Cost: GetEnvInt("BCRYPT_COST", bcrypt.DefaultCost)}
+	}
+
+	return Argon2idHasher{
+		Memory:      uint32(GetEnvInt("ARGON2_MEMORY_KIB", 64*1024)),
+		Iterations:  uint32(GetEnvInt("ARGON2_ITERATIONS", 3)),
+		Parallelism: uint8(GetEnvInt("ARGON2_PARALLELISM", 2)),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// HashPassword hashes the password with the configured hasher
+func HashPassword(password string) (string, error) {
+	return CurrentPasswordHasher().Hash(password)
+}
+
+// VerifyPassword checks a password against a hash produced by any supported hasher.
+// needsRehash is set when the hash does not match the configured algorithm or parameters.
+func VerifyPassword(password, encoded string) (ok bool, needsRehash bool) {
+	current := CurrentPasswordHasher()
+
+	for _, hasher := range []PasswordHasher{current, Argon2idHasher{}, BcryptHasher{}} {
+		if !hasher.Recognizes(encoded) {
+			continue
+		}
+
+		ok, outdated, err := hasher.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false
+		}
+		return true, outdated || !current.Recognizes(encoded)
+	}
+
+	return false, false
+}
+
+// Hash implements PasswordHasher
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)), nil
+}
+
+// Verify implements PasswordHasher
+func (h Argon2idHasher) Verify(password, encoded string) (bool, bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil || iterations < 1 || parallelism < 1 {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	salt, err := phcEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+	key, err := phcEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false, nil
+	}
+
+	outdated := memory != h.Memory || iterations != h.Iterations || parallelism != h.Parallelism ||
+		uint32(len(salt)) != h.SaltLength || uint32(len(key)) != h.KeyLength
+	return true, outdated, nil
+}
+
+// Recognizes implements PasswordHasher
+func (h Argon2idHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// Hash implements PasswordHasher
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// Verify implements PasswordHasher
+func (h BcryptHasher) Verify(password, encoded string) (bool, bool, error) {
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost != h.Cost, nil
+}
+
+// Recognizes implements PasswordHasher
+func (h BcryptHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
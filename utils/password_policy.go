@@ -0,0 +1,53 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// maxPasswordBytes keeps passwords usable with bcrypt, which ignores input beyond 72 bytes
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]bool {
+	passwords := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			passwords[strings.ToLower(line)] = true
+		}
+	}
+	return passwords
+}()
+
+// CheckPasswordPolicy returns why a password is not acceptable, or an empty string.
+// Passwords must be PASSWORD_MIN_LENGTH (default: 8) to 72 bytes long, must not be a
+// commonly used password and must not contain the local part of the user's email.
+func CheckPasswordPolicy(password, email string) string {
+	minLength := GetEnvInt("PASSWORD_MIN_LENGTH", 8)
+	if len([]rune(password)) < minLength {
+		return fmt.Sprintf("must be at least %d characters", minLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return "is too common"
+	}
+
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		local := email
+		if at := strings.LastIndex(email, "@"); at >= 0 {
+			local = email[:at]
+		}
+		if strings.Contains(lower, email) || (len(local) >= 3 && strings.Contains(lower, local)) {
+			return "must not contain your email address"
+		}
+	}
+
+	return ""
+}
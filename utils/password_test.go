@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// useFastPasswordHashing configures cheap hashing parameters for the test
+func useFastPasswordHashing(t *testing.T, hasher string) {
+	t.Helper()
+
+	t.Setenv("PASSWORD_HASHER", hasher)
+	t.Setenv("ARGON2_MEMORY_KIB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+	t.Setenv("BCRYPT_COST", "4")
+}
+
+func TestArgon2idHash(t *testing.T) {
+	useFastPasswordHashing(t, "argon2id")
+
+	first, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	second, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("HashPassword() = %s, want a PHC string with the configured parameters", first)
+	}
+	if first == second {
+		t.Error("HashPassword() returned the same hash twice; salts must be random")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hashWith := func(hasher PasswordHasher) string {
+		encoded, err := hasher.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		return encoded
+	}
+
+	argon2id := hashWith(Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	argon2idOtherMemory := hashWith(Argon2idHasher{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	argon2idOtherIterations := hashWith(Argon2idHasher{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	bcryptCurrent := hashWith(BcryptHasher{Cost: 4})
+	bcryptOtherCost := hashWith(BcryptHasher{Cost: 5})
+
+	tests := []struct {
+		name            string
+		hasher          string
+		password        string
+		encoded         string
+		wantOK          bool
+		wantNeedsRehash bool
+	}{
+		{name: "argon2id with current parameters", hasher: "argon2id", password: "correct horse", encoded: argon2id, wantOK: true},
+		{name: "argon2id wrong password", hasher: "argon2id", password: "wrong horse", encoded: argon2id},
+		{name: "argon2id with less memory", hasher: "argon2id", password: "correct horse", encoded: argon2idOtherMemory, wantOK: true, wantNeedsRehash: true},
+		{name: "argon2id with more iterations", hasher: "argon2id", password: "correct horse", encoded: argon2idOtherIterations, wantOK: true, wantNeedsRehash: true},
+		{name: "bcrypt while argon2id is configured", hasher: "argon2id", password: "correct horse", encoded: bcryptCurrent, wantOK: true, wantNeedsRehash: true},
+		{name: "bcrypt wrong password", hasher: "argon2id", password: "wrong horse", encoded: bcryptCurrent},
+		{name: "bcrypt with current cost", hasher: "bcrypt", password: "correct horse", encoded: bcryptCurrent, wantOK: true},
+		{name: "bcrypt with another cost", hasher: "bcrypt", password: "correct horse", encoded: bcryptOtherCost, wantOK: true, wantNeedsRehash: true},
+		{name: "argon2id while bcrypt is configured", hasher: "bcrypt", password: "correct horse", encoded: argon2id, wantOK: true, wantNeedsRehash: true},
+		{name: "tampered argon2id hash", hasher: "argon2id", password: "correct horse", encoded: argon2id[:len(argon2id)-4] + "AAAA"},
+		{name: "argon2id with a missing segment", hasher: "argon2id", password: "correct horse", encoded: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"},
+		{name: "argon2id with another version", hasher: "argon2id", password: "correct horse", encoded: strings.Replace(argon2id, "v=19", "v=16", 1)},
+		{name: "argon2id with zero iterations", hasher: "argon2id", password: "correct horse", encoded: strings.Replace(argon2id, "t=1", "t=0", 1)},
+		{name: "plain text", hasher: "argon2id", password: "correct horse", encoded: "correct horse"},
+		{name: "empty hash", hasher: "argon2id", password: "", encoded: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFastPasswordHashing(t, tt.hasher)
+
+			ok, needsRehash := VerifyPassword(tt.password, tt.encoded)
+			if ok != tt.wantOK || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("VerifyPassword() = %v, %v, want %v, %v", ok, needsRehash, tt.wantOK, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestRehashedPasswordIsCurrent(t *testing.T) {
+	useFastPasswordHashing(t, "bcrypt")
+	legacy, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	// Switching to argon2id asks for a rehash, after which the new hash is current
+	useFastPasswordHashing(t, "argon2id")
+	if ok, needsRehash := VerifyPassword("correct horse", legacy); !ok || !needsRehash {
+		t.Fatalf("VerifyPassword() of the bcrypt hash = %v, %v, want true, true", ok, needsRehash)
+	}
+	rehashed, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if ok, needsRehash := VerifyPassword("correct horse", rehashed); !ok || needsRehash {
+		t.Errorf("VerifyPassword() of the rehashed password = %v, %v, want true, false", ok, needsRehash)
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+// GenerateRandomToken returns a URL-safe random token built from n random bytes
+func GenerateRandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex encoded SHA-256 digest of a token so it can be stored at rest
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashRecoveryCode returns the hex encoded HMAC-SHA256 of a user's recovery code keyed with
+// RECOVERY_CODE_SECRET (falls back to JWT_SECRET). Recovery codes are short, so a plain digest
+// could be brute forced from a database dump; changing the secret invalidates stored codes.
+func HashRecoveryCode(userID uint, code string) (string, error) {
+	secret := GetEnv("RECOVERY_CODE_SECRET", GetEnv("JWT_SECRET", ""))
+	if secret == "" {
+		return "", errors.New("neither RECOVERY_CODE_SECRET nor JWT_SECRET is set")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("recovery:" + strconv.FormatUint(uint64(userID), 10) + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
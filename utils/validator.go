@@ -62,7 +62,7 @@ func ValidateStruct(s interface{}) ValidationErrors {
 		// Parse validation rules
 		rules := strings.Split(validateTag, ",")
 		for _, rule := range rules {
-			if err := validateField(field, fieldName, rule); err != nil {
+			if err := validateField(v, field, fieldName, rule); err != nil {
 				errors = append(errors, *err)
 			}
 		}
@@ -71,8 +71,8 @@ func ValidateStruct(s interface{}) ValidationErrors {
 	return errors
 }
 
-// validateField validates a single field based on a rule
-func validateField(field reflect.Value, fieldName, rule string) *ValidationError {
+// validateField validates a single field of the struct based on a rule
+func validateField(parent, field reflect.Value, fieldName, rule string) *ValidationError {
 	parts := strings.Split(rule, "=")
 	ruleName := parts[0]
 	var ruleValue string
@@ -120,6 +120,38 @@ func validateField(field reflect.Value, fieldName, rule string) *ValidationError
 				}
 			}
 		}
+	case "oneof":
+		// oneof=a b c accepts one of the space separated values
+		if field.Kind() == reflect.String && field.String() != "" {
+			allowed := strings.Fields(ruleValue)
+			found := false
+			for _, value := range allowed {
+				if field.String() == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return &ValidationError{
+					Field:   fieldName,
+					Message: "must be one of: " + strings.Join(allowed, ", "),
+				}
+			}
+		}
+	case "password":
+		// password=Email also rejects passwords resembling the named sibling field
+		if field.Kind() == reflect.String && field.String() != "" {
+			var email string
+			if sibling := parent.FieldByName(ruleValue); ruleValue != "" && sibling.Kind() == reflect.String {
+				email = sibling.String()
+			}
+			if message := CheckPasswordPolicy(field.String(), email); message != "" {
+				return &ValidationError{
+					Field:   fieldName,
+					Message: message,
+				}
+			}
+		}
 	}
 	
 	return nil